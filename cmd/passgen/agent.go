@@ -0,0 +1,563 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const agentSockEnv = "PASSGEN_AGENT_SOCK"
+
+// maxAgentSalt is the largest salt the agent holds; its buffer is one page.
+const maxAgentSalt = 4096 - 1
+
+// maxAgentRequest bounds a request line: a base64 salt of maxAgentSalt bytes
+// and the JSON around it.
+const maxAgentRequest = 8192
+
+var (
+	errAgentLocked         = errors.New("agent is locked (run 'passgen agent unlock')")
+	errPeerCredUnsupported = errors.New("peer credentials are not supported on this platform")
+)
+
+// lockedBuffer is memory that is kept out of swap where the platform allows.
+type lockedBuffer struct {
+	b []byte
+}
+
+// agentRequest and agentResponse are exchanged as single JSON lines, one
+// request per connection. The salt is a []byte, sent as base64, so that the
+// agent can wipe every copy it makes outside locked memory.
+type agentRequest struct {
+	Op   string `json:"op"`
+	Salt []byte `json:"salt,omitempty"`
+}
+
+type agentResponse struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	Salt      []byte `json:"salt,omitempty"`
+	Unlocked  bool   `json:"unlocked,omitempty"`
+	ExpiresIn int    `json:"expires_in,omitempty"`
+}
+
+// agentState holds the salt in locked memory and wipes it when the idle or
+// absolute timeout expires.
+type agentState struct {
+	mu       sync.Mutex
+	buf      *lockedBuffer
+	n        int
+	idle     time.Duration
+	lifetime time.Duration
+	deadline time.Time
+	lastUse  time.Time
+	timer    *time.Timer
+}
+
+func (a *agentState) unlock(salt []byte) error {
+	if len(salt) == 0 {
+		return errors.New("salt must not be empty")
+	}
+	if len(salt) > maxAgentSalt {
+		return fmt.Errorf("salt longer than %d bytes", maxAgentSalt)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.wipe()
+	a.n = copy(a.buf.b, salt)
+	now := time.Now()
+	a.lastUse = now
+	a.deadline = time.Time{}
+	if a.lifetime > 0 {
+		a.deadline = now.Add(a.lifetime)
+	}
+	a.schedule()
+	return nil
+}
+
+func (a *agentState) lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.wipe()
+}
+
+// useSalt calls fn with the salt in locked memory. fn must not keep it.
+func (a *agentState) useSalt(fn func(salt []byte)) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.n == 0 {
+		return errAgentLocked
+	}
+	a.lastUse = time.Now()
+	a.schedule()
+	fn(a.buf.b[:a.n])
+	return nil
+}
+
+func (a *agentState) status() (bool, time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.n == 0 {
+		return false, 0
+	}
+	return true, time.Until(a.expiry())
+}
+
+// expiry returns when the salt will be wiped, or the zero time if never.
+// Callers hold a.mu.
+func (a *agentState) expiry() time.Time {
+	var t time.Time
+	if a.idle > 0 {
+		t = a.lastUse.Add(a.idle)
+	}
+	if !a.deadline.IsZero() && (t.IsZero() || a.deadline.Before(t)) {
+		t = a.deadline
+	}
+	return t
+}
+
+// schedule arms the timer for the next expiry. Callers hold a.mu.
+func (a *agentState) schedule() {
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	t := a.expiry()
+	if t.IsZero() {
+		return
+	}
+	a.timer = time.AfterFunc(time.Until(t), func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if !time.Now().Before(a.expiry()) {
+			a.wipe()
+		}
+	})
+}
+
+// wipe zeroes the salt. Callers hold a.mu.
+func (a *agentState) wipe() {
+	clear(a.buf.b)
+	a.n = 0
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+}
+
+func (a *agentState) handle(conn *net.UnixConn, stop func()) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	if uid, err := peerUID(conn); err == nil && uid != os.Getuid() {
+		return
+	} else if err != nil && !errors.Is(err, errPeerCredUnsupported) {
+		return
+	}
+
+	buf := make([]byte, maxAgentRequest)
+	defer clear(buf)
+	line, err := readAgentRequest(conn, buf)
+	if err != nil {
+		return
+	}
+
+	var req agentRequest
+	defer func() { clear(req.Salt) }()
+	resp := agentResponse{OK: true}
+	if err := json.Unmarshal(line, &req); err != nil {
+		resp = agentResponse{Error: "invalid request"}
+	}
+
+	var out []byte
+	switch req.Op {
+	case "unlock":
+		if err := a.unlock(req.Salt); err != nil {
+			resp = agentResponse{Error: err.Error()}
+		}
+	case "lock":
+		a.lock()
+	case "salt":
+		err := a.useSalt(func(salt []byte) {
+			out = appendSaltResponse(make([]byte, 0, base64.StdEncoding.EncodedLen(len(salt))+32), salt)
+		})
+		if err != nil {
+			resp = agentResponse{Error: err.Error()}
+		}
+	case "status":
+		unlocked, left := a.status()
+		resp.Unlocked = unlocked
+		if unlocked && left > 0 {
+			resp.ExpiresIn = int(left.Round(time.Second) / time.Second)
+		}
+	case "kill":
+		a.lock()
+		defer stop()
+	default:
+		if resp.Error == "" {
+			resp = agentResponse{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+		}
+	}
+
+	if out == nil {
+		b, _ := json.Marshal(resp)
+		out = append(b, '\n')
+	}
+	conn.Write(out)
+	clear(out)
+}
+
+// readAgentRequest reads one request line into buf, which the caller wipes.
+// Unlike a bufio.Reader, it leaves no other copy of the salt behind.
+func readAgentRequest(conn net.Conn, buf []byte) ([]byte, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := conn.Read(buf[n:])
+		n += m
+		if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+			return buf[:i], nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.New("request too long")
+}
+
+// appendSaltResponse appends the response line for salt to dst. It encodes by
+// hand because encoding/json would leave a copy in its pooled buffers.
+func appendSaltResponse(dst, salt []byte) []byte {
+	dst = append(dst, `{"ok":true,"salt":"`...)
+	dst = base64.StdEncoding.AppendEncode(dst, salt)
+	return append(dst, "\"}\n"...)
+}
+
+// agentCall sends one request to the agent listening on sock.
+func agentCall(sock string, req agentRequest) (agentResponse, error) {
+	var resp agentResponse
+	conn, err := net.DialTimeout("unix", sock, 2*time.Second)
+	if err != nil {
+		return resp, fmt.Errorf("cannot reach agent at %s: %v", sock, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	if _, err := conn.Write(append(b, '\n')); err != nil {
+		return resp, err
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return resp, fmt.Errorf("reading agent response: %v", err)
+	}
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return resp, fmt.Errorf("invalid agent response: %v", err)
+	}
+	if !resp.OK {
+		if resp.Error == errAgentLocked.Error() {
+			return resp, errAgentLocked
+		}
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// agentSalt asks the agent named by PASSGEN_AGENT_SOCK for the salt.
+func agentSalt(sock string) (string, error) {
+	resp, err := agentCall(sock, agentRequest{Op: "salt"})
+	defer clear(resp.Salt)
+	return string(resp.Salt), err
+}
+
+// defaultAgentSocket returns $XDG_RUNTIME_DIR/passgen/agent.sock, or a
+// per-user directory under the system temporary directory.
+func defaultAgentSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "passgen", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), "passgen-"+strconv.Itoa(os.Getuid()), "agent.sock")
+}
+
+// prepareAgentDir creates the socket directory with mode 0700 and refuses
+// directories that other users could write to.
+func prepareAgentDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if fi.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("%s must not be accessible by other users (run chmod 700)", dir)
+	}
+	return checkOwner(fi)
+}
+
+func agentSocketFlag(fs *flagSet, p *string) {
+	fs.StringVar(p, "socket", "", "PATH", "", "Agent socket (default: $"+agentSockEnv+")")
+}
+
+func agentSocket(flag string) (string, error) {
+	if flag != "" {
+		return flag, nil
+	}
+	if sock := os.Getenv(agentSockEnv); sock != "" {
+		return sock, nil
+	}
+	return "", fmt.Errorf("%s is not set (start an agent with 'eval \"$(passgen agent)\"')", agentSockEnv)
+}
+
+var agentCommand = &command{
+	name:    "agent",
+	usage:   "[OPTIONS]",
+	summary: "Start an agent that keeps the salt in memory",
+	details: `Start the agent with 'eval "$(passgen agent)"'. It prints the
+PASSGEN_AGENT_SOCK variable that gen and the other commands use to fetch the
+salt. Unlock it with 'passgen agent unlock'. The salt is kept in locked memory
+and wiped after --idle without use or --lifetime after unlocking. The socket
+has mode 0600 and connections from other users are rejected.`,
+	examples: []example{
+		{"Start the agent in the current shell and unlock it", "eval \"$(passgen agent)\" && passgen agent unlock"},
+		{"Stop the agent", "passgen agent --kill"},
+	},
+	env:         []envVar{envAgentSock, envRuntimeDir},
+	envDefaults: []string{"idle", "lifetime"},
+	setup: func(fs *flagSet) func() error {
+		var socket string
+		var idle, lifetime time.Duration
+		var foreground, kill bool
+
+		fs.StringVar(&socket, "socket", "", "PATH", "", "Socket path (default: $XDG_RUNTIME_DIR/passgen/agent.sock)")
+		fs.DurationVar(&idle, "idle", "", "DURATION", 30*time.Minute, "Wipe the salt after this long without use (0 disables)")
+		fs.DurationVar(&lifetime, "lifetime", "", "DURATION", 8*time.Hour, "Wipe the salt this long after unlocking (0 disables)")
+		fs.BoolVar(&foreground, "foreground", "", "Run in the foreground instead of detaching").hidden = true
+		fs.BoolVar(&kill, "kill", "k", "Stop the agent named by --socket or $"+agentSockEnv)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+
+			if kill {
+				sock, err := agentSocket(socket)
+				if err != nil {
+					return err
+				}
+				if _, err := agentCall(sock, agentRequest{Op: "kill"}); err != nil {
+					return err
+				}
+				fmt.Printf("unset %s;\n", agentSockEnv)
+				return nil
+			}
+
+			if !agentSupported {
+				return errors.New("the agent is not supported on this platform")
+			}
+			if idle < 0 || lifetime < 0 {
+				return errors.New("--idle and --lifetime must not be negative")
+			}
+			if socket == "" {
+				socket = defaultAgentSocket()
+			}
+			socket, err := filepath.Abs(socket)
+			if err != nil {
+				return err
+			}
+			if err := prepareAgentDir(filepath.Dir(socket)); err != nil {
+				return err
+			}
+
+			if !foreground {
+				pid, err := startAgentProcess([]string{
+					"agent", "--foreground", "--socket", socket,
+					"--idle", idle.String(), "--lifetime", lifetime.String(),
+				})
+				if err != nil {
+					return err
+				}
+				if err := waitForAgent(socket, 3*time.Second); err != nil {
+					return err
+				}
+				fmt.Printf("%s=%s; export %s;\necho Agent pid %d;\n", agentSockEnv, shellQuote(socket), agentSockEnv, pid)
+				return nil
+			}
+
+			return runAgent(socket, idle, lifetime)
+		}
+	},
+}
+
+func waitForAgent(sock string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := agentCall(sock, agentRequest{Op: "status"})
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("agent did not start: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func runAgent(socket string, idle, lifetime time.Duration) error {
+	hardenAgentProcess()
+
+	buf, err := newLockedBuffer(maxAgentSalt + 1)
+	if err != nil {
+		return err
+	}
+	defer buf.free()
+
+	ln, err := listenUnix(socket)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	state := &agentState{buf: buf, idle: idle, lifetime: lifetime}
+	defer state.lock()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	fmt.Printf("%s=%s; export %s;\n", agentSockEnv, shellQuote(socket), agentSockEnv)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go state.handle(conn.(*net.UnixConn), stop)
+	}
+}
+
+var agentUnlockCommand = &command{
+	name:        "agent unlock",
+	usage:       "[OPTIONS]",
+	summary:     "Give the salt to the running agent",
+	details:     "The salt is prompted for without echo, or read from --salt-fd or a vault entry\nnamed by --salt-ref.",
+	env:         []envVar{envAgentSock, envRuntimeDir, envDataHome},
+	envDefaults: []string{"vault"},
+	setup: func(fs *flagSet) func() error {
+		var socket string
+		secrets := secretOptions{inputFD: -1, prompt: true, noAgent: true}
+
+		agentSocketFlag(fs, &socket)
+		fs.IntVar(&secrets.saltFD, "salt-fd", "", "FD", -1, "Read the salt from file descriptor FD")
+		fs.StringVar(&secrets.saltRef, "salt-ref", "", "NAME", "", "Read the salt from the vault entry NAME")
+		fs.BoolVar(&secrets.confirmSalt, "confirm-salt", "", "Prompt for the salt twice and require both to match")
+		secrets.vault.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			sock, err := agentSocket(socket)
+			if err != nil {
+				return err
+			}
+
+			var salt string
+			if err := secrets.resolveSalt(&salt); err != nil {
+				return err
+			}
+			if salt == "" {
+				return errors.New("salt must not be empty")
+			}
+			req := agentRequest{Op: "unlock", Salt: []byte(salt)}
+			defer clear(req.Salt)
+			_, err = agentCall(sock, req)
+			return err
+		}
+	},
+}
+
+var agentLockCommand = &command{
+	name:    "agent lock",
+	usage:   "[OPTIONS]",
+	summary: "Wipe the salt from the running agent",
+	env:     []envVar{envAgentSock, envRuntimeDir},
+	setup: func(fs *flagSet) func() error {
+		var socket string
+		agentSocketFlag(fs, &socket)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			sock, err := agentSocket(socket)
+			if err != nil {
+				return err
+			}
+			_, err = agentCall(sock, agentRequest{Op: "lock"})
+			return err
+		}
+	},
+}
+
+var agentStatusCommand = &command{
+	name:    "agent status",
+	usage:   "[OPTIONS]",
+	summary: "Show whether the agent holds a salt",
+	details: "Exits 0 when the agent is unlocked and 1 when it is locked or unreachable.",
+	env:     []envVar{envAgentSock, envRuntimeDir},
+	setup: func(fs *flagSet) func() error {
+		var socket string
+		agentSocketFlag(fs, &socket)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			sock, err := agentSocket(socket)
+			if err != nil {
+				return err
+			}
+			resp, err := agentCall(sock, agentRequest{Op: "status"})
+			if err != nil {
+				return err
+			}
+
+			if !resp.Unlocked {
+				fmt.Println("locked")
+				return exitCode(1)
+			}
+			if resp.ExpiresIn > 0 {
+				fmt.Printf("unlocked (locks in %s)\n", time.Duration(resp.ExpiresIn)*time.Second)
+			} else {
+				fmt.Println("unlocked")
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// From <sys/un.h> and <sys/ucred.h>; the syscall package lacks them.
+const (
+	solLocal      = 0
+	localPeerCred = 0x001
+	xucredVersion = 0
+)
+
+// peerUID returns the user ID of the process at the other end of conn, read
+// from the struct xucred of LOCAL_PEERCRED. The syscall package has no getter
+// for that struct, so this borrows GetsockoptIPv6Mreq: the kernel truncates
+// the struct to its 20-byte buffer, which starts with cr_version and cr_uid.
+func peerUID(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var cred *syscall.IPv6Mreq
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptIPv6Mreq(int(fd), solLocal, localPeerCred)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, credErr
+	}
+	if v := binary.NativeEndian.Uint32(cred.Multiaddr[0:4]); v != xucredVersion {
+		return 0, fmt.Errorf("unexpected xucred version %d", v)
+	}
+	return int(binary.NativeEndian.Uint32(cred.Multiaddr[4:8])), nil
+}
+
+func disableTracing() {}
@@ -0,0 +1,35 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+)
+
+const agentSupported = false
+
+var errAgentUnsupported = errors.New("the agent is not supported on this platform")
+
+func newLockedBuffer(int) (*lockedBuffer, error) {
+	return nil, errAgentUnsupported
+}
+
+func (l *lockedBuffer) free() {
+	clear(l.b)
+}
+
+func hardenAgentProcess() {}
+
+func startAgentProcess([]string) (int, error) {
+	return 0, errAgentUnsupported
+}
+
+func checkOwner(fs.FileInfo) error {
+	return nil
+}
+
+func peerUID(*net.UnixConn) (int, error) {
+	return 0, errPeerCredUnsupported
+}
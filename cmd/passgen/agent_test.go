@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func startTestAgent(t *testing.T, idle, lifetime time.Duration) string {
+	t.Helper()
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	ln, err := listenUnix(sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	state := &agentState{buf: &lockedBuffer{b: make([]byte, maxAgentSalt+1)}, idle: idle, lifetime: lifetime}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go state.handle(conn.(*net.UnixConn), func() { ln.Close() })
+		}
+	}()
+	return sock
+}
+
+func TestAgent_UnlockLock(t *testing.T) {
+	sock := startTestAgent(t, 0, 0)
+
+	if _, err := agentSalt(sock); !errors.Is(err, errAgentLocked) {
+		t.Fatalf("agentSalt() before unlock error = %v, want errAgentLocked", err)
+	}
+	if _, err := agentCall(sock, agentRequest{Op: "unlock", Salt: []byte("pepper")}); err != nil {
+		t.Fatalf("unlock error = %v", err)
+	}
+	if s, err := agentSalt(sock); err != nil || s != "pepper" {
+		t.Fatalf("agentSalt() = %q, %v, want pepper", s, err)
+	}
+	if resp, err := agentCall(sock, agentRequest{Op: "status"}); err != nil || !resp.Unlocked || resp.ExpiresIn != 0 {
+		t.Errorf("status = %+v, %v, want unlocked without expiry", resp, err)
+	}
+
+	if _, err := agentCall(sock, agentRequest{Op: "lock"}); err != nil {
+		t.Fatalf("lock error = %v", err)
+	}
+	if _, err := agentSalt(sock); !errors.Is(err, errAgentLocked) {
+		t.Errorf("agentSalt() after lock error = %v, want errAgentLocked", err)
+	}
+
+	if _, err := agentCall(sock, agentRequest{Op: "unlock"}); err == nil {
+		t.Error("unlock with an empty salt should fail")
+	}
+	if _, err := agentCall(sock, agentRequest{Op: "dump"}); err == nil {
+		t.Error("an unknown operation should fail")
+	}
+}
+
+func TestAgent_Timeouts(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		idle, lifetime time.Duration
+	}{
+		{"idle", 50 * time.Millisecond, 0},
+		{"lifetime", time.Hour, 50 * time.Millisecond},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			sock := startTestAgent(t, tt.idle, tt.lifetime)
+			if _, err := agentCall(sock, agentRequest{Op: "unlock", Salt: []byte("pepper")}); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(150 * time.Millisecond)
+			if _, err := agentSalt(sock); !errors.Is(err, errAgentLocked) {
+				t.Errorf("agentSalt() after %s timeout error = %v, want errAgentLocked", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestAgent_Kill(t *testing.T) {
+	sock := startTestAgent(t, 0, 0)
+	if _, err := agentCall(sock, agentRequest{Op: "kill"}); err != nil {
+		t.Fatalf("kill error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := agentCall(sock, agentRequest{Op: "status"}); err == nil {
+		t.Error("the agent should stop listening after kill")
+	}
+}
+
+func TestAppendSaltResponse(t *testing.T) {
+	line := appendSaltResponse(nil, []byte("pep\"per"))
+	var resp agentResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.Fatalf("appendSaltResponse() = %q is not JSON: %v", line, err)
+	}
+	if !resp.OK || string(resp.Salt) != "pep\"per" || line[len(line)-1] != '\n' {
+		t.Errorf("appendSaltResponse() = %q", line)
+	}
+}
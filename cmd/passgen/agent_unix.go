@@ -0,0 +1,73 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const agentSupported = true
+
+// newLockedBuffer maps n bytes of anonymous memory and locks them so the salt
+// is never written to swap.
+func newLockedBuffer(n int) (*lockedBuffer, error) {
+	b, err := syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, fmt.Errorf("allocating agent memory: %v", err)
+	}
+	if err := syscall.Mlock(b); err != nil {
+		syscall.Munmap(b)
+		return nil, fmt.Errorf("locking agent memory: %v (check ulimit -l)", err)
+	}
+	return &lockedBuffer{b: b}, nil
+}
+
+func (l *lockedBuffer) free() {
+	clear(l.b)
+	syscall.Munlock(l.b)
+	syscall.Munmap(l.b)
+	l.b = nil
+}
+
+// hardenAgentProcess disables core dumps, and on Linux tracing by other
+// processes of the same user, so the salt cannot be read back out.
+func hardenAgentProcess() {
+	syscall.Setrlimit(syscall.RLIMIT_CORE, &syscall.Rlimit{})
+	disableTracing()
+}
+
+// startAgentProcess runs this executable with args in a new session, detached
+// from the terminal, and returns its process ID.
+func startAgentProcess(args []string) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer null.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = null, null, null
+	cmd.Dir = "/"
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("starting agent: %v", err)
+	}
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+	return pid, nil
+}
+
+func checkOwner(fi fs.FileInfo) error {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user", fi.Name())
+	}
+	return nil
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+	"github.com/zapsaang/pass-gen/pkg/passgen/vault"
+)
+
+// batchColumns are the fields accepted in a manifest row.
+var batchColumns = []string{"name", "type", "input", "site", "length", "level", "encoding", "counter", "algo_version", "salt_ref"}
+
+type batchRow struct {
+	line   int
+	fields map[string]string
+}
+
+func (r batchRow) int(key string, def int) (int, error) {
+	v := r.fields[key]
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer", key)
+	}
+	return n, nil
+}
+
+// readManifest calls fn for every row of a CSV or JSONL manifest. Errors in a
+// row are passed to fn with the row's line number; errors that make the rest
+// of the manifest unreadable are returned.
+func readManifest(r io.Reader, format string, fn func(batchRow, error) error) error {
+	br := bufio.NewReader(r)
+	if format == "" {
+		format = "csv"
+		if b, err := br.Peek(1); err == nil && b[0] == '{' {
+			format = "jsonl"
+		}
+	}
+
+	switch format {
+	case "csv":
+		return readCSVManifest(br, fn)
+	case "jsonl":
+		return readJSONLManifest(br, fn)
+	}
+	return fmt.Errorf("invalid manifest format %q (use csv or jsonl)", format)
+}
+
+func readCSVManifest(r io.Reader, fn func(batchRow, error) error) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i, h := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(h))
+		if !isBatchColumn(header[i]) {
+			return fmt.Errorf("line 1: unknown column %q (use %s)", h, strings.Join(batchColumns, ", "))
+		}
+	}
+
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				if err := fn(batchRow{line: perr.StartLine}, perr.Err); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		line, _ := cr.FieldPos(0)
+		row := batchRow{line: line, fields: map[string]string{}}
+		if len(rec) > len(header) {
+			if err := fn(row, fmt.Errorf("expected %d fields, got %d", len(header), len(rec))); err != nil {
+				return err
+			}
+			continue
+		}
+		for i, v := range rec {
+			row.fields[header[i]] = strings.TrimSpace(v)
+		}
+		if err := fn(row, nil); err != nil {
+			return err
+		}
+	}
+}
+
+func readJSONLManifest(r *bufio.Reader, fn func(batchRow, error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row, err := parseJSONRow(line, text)
+		if err := fn(row, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parseJSONRow(line int, text []byte) (batchRow, error) {
+	row := batchRow{line: line, fields: map[string]string{}}
+
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return row, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	for k, v := range raw {
+		if !isBatchColumn(k) {
+			return row, fmt.Errorf("unknown field %q", k)
+		}
+		switch x := v.(type) {
+		case string:
+			row.fields[k] = x
+		case json.Number:
+			row.fields[k] = x.String()
+		case nil:
+		default:
+			return row, fmt.Errorf("%s must be a string or number", k)
+		}
+	}
+	return row, nil
+}
+
+func isBatchColumn(name string) bool {
+	for _, c := range batchColumns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+type batchResult struct {
+	name     string
+	kind     string
+	password string
+	record   record
+}
+
+// batchWriter writes generated secrets as JSONL, CSV or one file per row.
+type batchWriter struct {
+	format string
+	out    io.Writer
+	file   *os.File
+	csv    *csv.Writer
+	dir    string
+}
+
+// newBatchWriter opens the output. Files are created with mode 0600 and are
+// never overwritten.
+func newBatchWriter(format, output string) (*batchWriter, error) {
+	w := &batchWriter{format: format, out: os.Stdout}
+
+	switch format {
+	case "dir":
+		if output == "" || output == "-" {
+			return nil, errors.New("--format dir needs -o/--output DIR")
+		}
+		if err := os.MkdirAll(output, 0o700); err != nil {
+			return nil, err
+		}
+		w.dir = output
+		return w, nil
+	case "jsonl", "csv":
+	default:
+		return nil, fmt.Errorf("invalid output format %q (use jsonl, csv or dir)", format)
+	}
+
+	if output != "" && output != "-" {
+		f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		w.out, w.file = f, f
+	}
+	if format == "csv" {
+		w.csv = csv.NewWriter(w.out)
+		if err := w.csv.Write([]string{"line", "name", "type", "password"}); err != nil {
+			w.close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *batchWriter) write(line int, res batchResult) error {
+	switch w.format {
+	case "jsonl":
+		b, err := json.Marshal(res.record)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w.out, "%s\n", b)
+		return err
+	case "csv":
+		return w.csv.Write([]string{strconv.Itoa(line), res.name, res.kind, res.password})
+	}
+
+	file := batchFileName(res.name)
+	if file == "" {
+		return errors.New("name cannot be used as a file name")
+	}
+	f, err := os.OpenFile(filepath.Join(w.dir, file), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists in %s", file, w.dir)
+		}
+		return err
+	}
+	if _, err := fmt.Fprintln(f, res.password); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (w *batchWriter) close() error {
+	var err error
+	if w.csv != nil {
+		w.csv.Flush()
+		err = w.csv.Error()
+	}
+	if w.file != nil {
+		if cerr := w.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// batchFileName maps a row name to a file name, replacing path separators
+// and other characters that are awkward in file names.
+func batchFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, name)
+	if strings.Trim(name, ".") == "" {
+		return ""
+	}
+	return name
+}
+
+var batchCommand = &command{
+	name:    "batch",
+	usage:   "[OPTIONS] [MANIFEST]",
+	summary: "Generate many secrets from a CSV or JSONL manifest",
+	details: `Each row has some of the fields name, type (gen or random), input, site,
+length, level, encoding, counter, algo_version and salt_ref. CSV manifests need
+a header row. Rows without salt_ref use the salt given with --salt, --salt-fd,
+--salt-ref or PASSGEN_SALT. Errors are reported per row with the line number
+and the remaining rows are still generated.`,
+	examples: []example{
+		{"Generate every row of a manifest into one file per row", "passgen batch --format dir -o secrets/ manifest.csv"},
+	},
+	env:         []envVar{envSalt, envAgentSock, envConfigHome, envDataHome},
+	envDefaults: slices.Concat(envGenerator, envFiles, []string{"manifest-format"}),
+	setup: func(fs *flagSet) func() error {
+		var salt, level, encoding, manifestFormat, format, output string
+		var length, counter, algoVersion int
+		var secrets secretOptions
+		var config configOptions
+		var store storeOptions
+
+		fs.StringVar(&salt, "salt", "s", "TEXT", "", "Default salt (or PASSGEN_SALT)")
+		fs.IntVar(&length, "length", "l", "NUM", 64, "Default length")
+		fs.StringVar(&level, "level", "L", "LEVEL", "medium", "Default security level")
+		fs.IntVar(&counter, "counter", "c", "NUM", 1, "Default counter")
+		fs.StringVar(&encoding, "encoding", "", "ENC", "", "Default encoding")
+		fs.IntVar(&algoVersion, "algo-version", "", "NUM", passgen.AlgorithmVersion, "Default generation algorithm version")
+		fs.StringVar(&manifestFormat, "manifest-format", "", "FMT", "", "Manifest format: csv or jsonl (default: detected)")
+		fs.StringVar(&format, "format", "", "FMT", "jsonl", "Output format: jsonl, csv or dir")
+		fs.StringVar(&output, "output", "o", "PATH", "", "Output file, or directory for --format dir (default: stdout)")
+		secrets.register(fs)
+		config.register(fs)
+		store.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 1 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[1])
+			}
+			if err := config.apply(fs); err != nil {
+				return err
+			}
+			if secrets.inputStdin || secrets.inputFD >= 0 || secrets.inputRef != "" {
+				return errors.New("inputs come from the manifest; --input-stdin, --input-fd and --input-ref cannot be used")
+			}
+
+			var in io.Reader = os.Stdin
+			if len(fs.Args()) == 1 && fs.Args()[0] != "-" {
+				path := fs.Args()[0]
+				f, err := os.Open(path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+				if manifestFormat == "" {
+					switch strings.ToLower(filepath.Ext(path)) {
+					case ".csv":
+						manifestFormat = "csv"
+					case ".jsonl", ".ndjson":
+						manifestFormat = "jsonl"
+					}
+				}
+			}
+
+			w, err := newBatchWriter(format, output)
+			if err != nil {
+				return err
+			}
+
+			var st *site.Store
+			saltResolved := false
+			failed, total := 0, 0
+
+			generate := func(row batchRow) (batchResult, error) {
+				f := row.fields
+				kind := f["type"]
+				if kind == "" {
+					kind = "gen"
+				}
+
+				rowLength, err := row.int("length", length)
+				if err != nil {
+					return batchResult{}, err
+				}
+				rowEncoding := cmp.Or(f["encoding"], encoding)
+
+				if kind == "random" {
+					if f["input"] != "" || f["site"] != "" || f["salt_ref"] != "" {
+						return batchResult{}, errors.New("random rows take no input, site or salt_ref")
+					}
+					var password string
+					if rowEncoding != "" {
+						password, err = passgen.GenerateRandomEncoded(rowLength, passgen.Encoding(rowEncoding))
+					} else {
+						password, err = passgen.GenerateRandomString(rowLength)
+					}
+					if err != nil {
+						return batchResult{}, err
+					}
+					name := cmp.Or(f["name"], "row-"+strconv.Itoa(row.line))
+					r := newRecord("batch").with("line", row.line).with("name", name).with("type", kind).with("password", password)
+					if rowEncoding != "" {
+						r = r.with("encoding", rowEncoding)
+					}
+					return batchResult{name, kind, password, r.with("length", rowLength)}, nil
+				}
+				if kind != "gen" {
+					return batchResult{}, fmt.Errorf("invalid type %q (use gen or random)", kind)
+				}
+
+				cfg := passgen.Config{
+					Input:    f["input"],
+					Length:   rowLength,
+					Level:    passgen.Level(cmp.Or(f["level"], level)),
+					Encoding: passgen.Encoding(rowEncoding),
+				}
+				if cfg.Counter, err = row.int("counter", counter); err != nil {
+					return batchResult{}, err
+				}
+				if cfg.Version, err = row.int("algo_version", algoVersion); err != nil {
+					return batchResult{}, err
+				}
+
+				if name := f["site"]; name != "" {
+					if cfg.Input != "" {
+						return batchResult{}, errors.New("input and site are mutually exclusive")
+					}
+					if st == nil {
+						if st, err = store.open(); err != nil {
+							return batchResult{}, err
+						}
+					}
+					s, err := st.Get(name)
+					if err != nil {
+						return batchResult{}, err
+					}
+					// Manifest columns override the stored parameters.
+					stored := s.Config("")
+					cfg.Input = stored.Input
+					if f["length"] == "" {
+						cfg.Length = stored.Length
+					}
+					if f["level"] == "" {
+						cfg.Level = stored.Level
+					}
+					if f["encoding"] == "" {
+						cfg.Encoding = stored.Encoding
+					}
+					if f["counter"] == "" {
+						cfg.Counter = stored.Counter
+					}
+					if f["algo_version"] == "" {
+						cfg.Version = stored.Version
+					}
+				}
+				if cfg.Input == "" {
+					return batchResult{}, errors.New("input or site is required")
+				}
+
+				if ref := f["salt_ref"]; ref != "" {
+					if cfg.Salt, err = secrets.vaultValue(ref, vault.KindSalt); err != nil {
+						return batchResult{}, err
+					}
+				} else {
+					if !saltResolved {
+						if err := secrets.resolveSalt(&salt); err != nil {
+							return batchResult{}, err
+						}
+						saltResolved = true
+					}
+					cfg.Salt = salt
+				}
+
+				password, err := passgen.Generate(cfg)
+				if err != nil {
+					return batchResult{}, err
+				}
+
+				name := cmp.Or(f["name"], cfg.Input)
+				r := newRecord("batch").with("line", row.line).with("name", name).with("type", kind).with("password", password)
+				if cfg.Encoding != passgen.EncodingNone {
+					r = r.with("encoding", string(cfg.Encoding))
+				} else {
+					r = r.with("level", string(cfg.Level))
+				}
+				r = r.with("length", cfg.Length).with("counter", max(cfg.Counter, 1))
+				return batchResult{name, kind, password, r}, nil
+			}
+
+			err = readManifest(in, manifestFormat, func(row batchRow, rowErr error) error {
+				total++
+				if rowErr == nil {
+					var res batchResult
+					if res, rowErr = generate(row); rowErr == nil {
+						rowErr = w.write(row.line, res)
+					}
+				}
+				if rowErr != nil {
+					failed++
+					fmt.Fprintf(os.Stderr, "line %d: %v\n", row.line, rowErr)
+				}
+				return nil
+			})
+			if closeErr := w.close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				fmt.Fprintf(os.Stderr, "%d of %d rows failed\n", failed, total)
+				return exitCode(1)
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type manifestResult struct {
+	line   int
+	fields map[string]string
+	err    string
+}
+
+func collectManifest(t *testing.T, content, format string) ([]manifestResult, error) {
+	t.Helper()
+	var got []manifestResult
+	err := readManifest(strings.NewReader(content), format, func(row batchRow, err error) error {
+		r := manifestResult{line: row.line, fields: row.fields}
+		if err != nil {
+			r.err = err.Error()
+			r.fields = nil
+		}
+		got = append(got, r)
+		return nil
+	})
+	return got, err
+}
+
+func TestReadManifest_CSV(t *testing.T) {
+	content := "name,input,length\n" +
+		"db,postgres,16\n" +
+		"# comment\n" +
+		"\n" +
+		"\"multi\nline\",x,\n" +
+		"a,b,c,d\n"
+
+	got, err := collectManifest(t, content, "")
+	if err != nil {
+		t.Fatalf("readManifest() error = %v", err)
+	}
+	want := []manifestResult{
+		{line: 2, fields: map[string]string{"name": "db", "input": "postgres", "length": "16"}},
+		{line: 5, fields: map[string]string{"name": "multi\nline", "input": "x", "length": ""}},
+		{line: 7, err: "expected 3 fields, got 4"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readManifest() = %+v, want %+v", got, want)
+	}
+
+	// Quoting errors are reported for their row; later rows still run.
+	got, err = collectManifest(t, "name,length\nfoo,12\nba\"r,12\nbaz,8\n\"a\n", "csv")
+	if err != nil {
+		t.Fatalf("readManifest() with a bad quote error = %v", err)
+	}
+	want = []manifestResult{
+		{line: 2, fields: map[string]string{"name": "foo", "length": "12"}},
+		{line: 3, err: `bare " in non-quoted-field`},
+		{line: 4, fields: map[string]string{"name": "baz", "length": "8"}},
+		{line: 5, err: `extraneous or missing " in quoted-field`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readManifest() with bad quotes = %+v, want %+v", got, want)
+	}
+
+	if _, err := collectManifest(t, "name,colour\n", "csv"); err == nil || !strings.Contains(err.Error(), `line 1: unknown column "colour"`) {
+		t.Errorf("readManifest() with unknown column error = %v", err)
+	}
+}
+
+func TestReadManifest_JSONL(t *testing.T) {
+	content := `{"input": "postgres", "length": 16, "level": null}` + "\n" +
+		"\n" +
+		`{"site": "github.com", "extra": 1}` + "\n" +
+		`{"input": true}` + "\n" +
+		"not json\n"
+
+	got, err := collectManifest(t, content, "")
+	if err != nil {
+		t.Fatalf("readManifest() error = %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("readManifest() returned %d rows, want 4", len(got))
+	}
+	if got[0].line != 1 || !reflect.DeepEqual(got[0].fields, map[string]string{"input": "postgres", "length": "16"}) {
+		t.Errorf("row 1 = %+v", got[0])
+	}
+	for i, want := range []struct {
+		line int
+		err  string
+	}{
+		{3, `unknown field "extra"`},
+		{4, "input must be a string or number"},
+		{5, "invalid JSON"},
+	} {
+		r := got[i+1]
+		if r.line != want.line || !strings.Contains(r.err, want.err) {
+			t.Errorf("row %d = %+v, want line %d error %q", i+2, r, want.line, want.err)
+		}
+	}
+}
+
+func TestBatchWriter_Dir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	w, err := newBatchWriter("dir", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.write(1, batchResult{name: "db/prod", password: "secret"}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := w.write(2, batchResult{name: "db/prod", password: "other"}); err == nil {
+		t.Error("write() should refuse to overwrite an existing file")
+	}
+	if err := w.write(3, batchResult{name: "..", password: "x"}); err == nil {
+		t.Error("write() should reject a name that is not a usable file name")
+	}
+
+	path := filepath.Join(dir, "db_prod")
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file mode = %o, want 600", perm)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "secret\n" {
+		t.Errorf("file content = %q", b)
+	}
+}
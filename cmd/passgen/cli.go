@@ -0,0 +1,347 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errHelp = errors.New("help requested")
+
+type flagKind int
+
+const (
+	boolFlag flagKind = iota
+	stringFlag
+	intFlag
+	durationFlag
+)
+
+type option struct {
+	long    string
+	short   string
+	metavar string
+	usage   string
+	kind    flagKind
+	def     string
+	hidden  bool
+	changed bool
+	source  string
+	set     func(string) error
+	get     func() string
+}
+
+// flagSet is a small GNU-style option parser. It accepts --name value,
+// --name=value, -x value, -xvalue, clustered boolean shorthands such as -ab,
+// options interleaved with positional arguments, and "--" to end options.
+type flagSet struct {
+	name    string
+	options []*option
+	args    []string
+
+	// stopAtArg ends option parsing at the first positional argument, for
+	// commands that pass the rest of the line on to another command.
+	stopAtArg bool
+}
+
+func newFlagSet(name string) *flagSet {
+	return &flagSet{name: name}
+}
+
+func (fs *flagSet) add(o *option) *option {
+	for _, existing := range fs.options {
+		if existing.long == o.long || (o.short != "" && existing.short == o.short) {
+			panic("passgen: duplicate flag " + o.long)
+		}
+	}
+	fs.options = append(fs.options, o)
+	return o
+}
+
+func (fs *flagSet) StringVar(p *string, long, short, metavar, def, usage string) *option {
+	*p = def
+	return fs.add(&option{
+		long: long, short: short, metavar: metavar, usage: usage, kind: stringFlag, def: def,
+		set: func(v string) error {
+			*p = v
+			return nil
+		},
+		get: func() string { return *p },
+	})
+}
+
+func (fs *flagSet) IntVar(p *int, long, short, metavar string, def int, usage string) *option {
+	*p = def
+	return fs.add(&option{
+		long: long, short: short, metavar: metavar, usage: usage, kind: intFlag, def: strconv.Itoa(def),
+		set: func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New("must be an integer")
+			}
+			*p = n
+			return nil
+		},
+		get: func() string { return strconv.Itoa(*p) },
+	})
+}
+
+func (fs *flagSet) DurationVar(p *time.Duration, long, short, metavar string, def time.Duration, usage string) *option {
+	*p = def
+	return fs.add(&option{
+		long: long, short: short, metavar: metavar, usage: usage, kind: durationFlag, def: def.String(),
+		set: func(v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return errors.New("must be a duration such as 45s or 2m")
+			}
+			*p = d
+			return nil
+		},
+		get: func() string { return p.String() },
+	})
+}
+
+func (fs *flagSet) BoolVar(p *bool, long, short, usage string) *option {
+	*p = false
+	return fs.add(&option{
+		long: long, short: short, usage: usage, kind: boolFlag,
+		set: func(v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.New("must be true or false")
+			}
+			*p = b
+			return nil
+		},
+		get: func() string { return strconv.FormatBool(*p) },
+	})
+}
+
+func (fs *flagSet) Args() []string {
+	return fs.args
+}
+
+func (fs *flagSet) Changed(long string) bool {
+	o := fs.lookupLong(long)
+	return o != nil && o.changed
+}
+
+// Source reports where the value of an option came from: "flag", "env", a
+// layer name such as "profile work", or "" for the default.
+func (fs *flagSet) Source(long string) string {
+	if o := fs.lookupLong(long); o != nil {
+		return o.source
+	}
+	return ""
+}
+
+func (fs *flagSet) lookupLong(name string) *option {
+	for _, o := range fs.options {
+		if o.long == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (fs *flagSet) lookupShort(name string) *option {
+	for _, o := range fs.options {
+		if o.short != "" && o.short == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (fs *flagSet) Parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--":
+			fs.args = append(fs.args, args[i+1:]...)
+			return nil
+
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			if name == "help" {
+				return errHelp
+			}
+			o := fs.lookupLong(name)
+			if o == nil {
+				return fmt.Errorf("unknown flag: --%s", name)
+			}
+			if o.kind == boolFlag && !hasValue {
+				value = "true"
+			} else if !hasValue {
+				if i+1 >= len(args) {
+					return fmt.Errorf("flag needs an argument: --%s", name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := fs.set(o, value); err != nil {
+				return err
+			}
+
+		case len(arg) > 1 && arg[0] == '-':
+			consumedNext, err := fs.parseShort(arg, args[i+1:])
+			if err != nil {
+				return err
+			}
+			if consumedNext {
+				i++
+			}
+
+		default:
+			if fs.stopAtArg {
+				fs.args = append(fs.args, args[i:]...)
+				return nil
+			}
+			fs.args = append(fs.args, arg)
+		}
+	}
+	return nil
+}
+
+func (fs *flagSet) parseShort(arg string, rest []string) (bool, error) {
+	for j := 1; j < len(arg); j++ {
+		name := arg[j : j+1]
+		if name == "h" {
+			return false, errHelp
+		}
+		o := fs.lookupShort(name)
+		if o == nil {
+			return false, fmt.Errorf("unknown shorthand flag: -%s in %s", name, arg)
+		}
+		if o.kind == boolFlag {
+			if err := fs.set(o, "true"); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if value := arg[j+1:]; value != "" {
+			return false, fs.set(o, value)
+		}
+		if len(rest) == 0 {
+			return false, fmt.Errorf("flag needs an argument: -%s", name)
+		}
+		return true, fs.set(o, rest[0])
+	}
+	return false, nil
+}
+
+func (fs *flagSet) set(o *option, value string) error {
+	if err := o.set(value); err != nil {
+		return fmt.Errorf("invalid value %q for --%s: %v", value, o.long, err)
+	}
+	o.changed = true
+	o.source = "flag"
+	return nil
+}
+
+// applyLayer sets options from a lower-precedence layer such as a
+// configuration file. Options already set by a flag or an earlier layer are
+// left alone; source records where each value came from.
+func (fs *flagSet) applyLayer(values map[string]string, source string) error {
+	return fs.applyValues(values, source, false)
+}
+
+// applySiteLayer applies the stored parameters of a site. They rank above
+// the environment, so values read from PASSGEN_<OPTION> are replaced too: a
+// variable meant as a default must not change the password of a stored site.
+func (fs *flagSet) applySiteLayer(values map[string]string, source string) error {
+	return fs.applyValues(values, source, true)
+}
+
+func (fs *flagSet) applyValues(values map[string]string, source string, overEnv bool) error {
+	for key, value := range values {
+		long := key
+		if name, ok := configKeys[key]; ok {
+			long = name
+		}
+		o := fs.lookupLong(long)
+		if o == nil || (o.source != "" && !(overEnv && o.source == "env")) {
+			continue
+		}
+		if err := o.set(value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for %s: %v", source, value, key, err)
+		}
+		o.source = source
+	}
+	return nil
+}
+
+// envPrefix starts the environment variable of every option, so that
+// --algo-version defaults to $PASSGEN_ALGO_VERSION.
+const envPrefix = "PASSGEN_"
+
+func envName(o *option) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(o.long, "-", "_"))
+}
+
+// applyEnv sets the options named in allowed that were not given on the
+// command line from their environment variables. Empty variables count as
+// unset.
+func (fs *flagSet) applyEnv(lookup func(string) (string, bool), allowed []string) error {
+	for _, o := range fs.options {
+		if !slices.Contains(allowed, o.long) || o.source != "" {
+			continue
+		}
+		name := envName(o)
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := o.set(value); err != nil {
+			return fmt.Errorf("%s: invalid value %q for --%s: %v", name, value, o.long, err)
+		}
+		o.source = "env"
+	}
+	return nil
+}
+
+// optionRows returns the visible options as flag and description pairs,
+// ending with --help. Help, the man page and the Markdown reference share it.
+func (fs *flagSet) optionRows() [][2]string {
+	var rows [][2]string
+	for _, o := range fs.options {
+		if o.hidden {
+			continue
+		}
+
+		left := "    "
+		if o.short != "" {
+			left = "-" + o.short + ", "
+		}
+		left += "--" + o.long
+		if o.metavar != "" {
+			left += " " + o.metavar
+		}
+
+		usage := o.usage
+		if o.def != "" && o.kind != boolFlag && o.def != "0" && o.def != "0s" {
+			usage += fmt.Sprintf(" (default: %s)", o.def)
+		}
+
+		rows = append(rows, [2]string{left, usage})
+	}
+	return append(rows, [2]string{"-h, --help", "Show this help message"})
+}
+
+func (fs *flagSet) PrintOptions(w io.Writer) {
+	rows := fs.optionRows()
+	width := 0
+	for _, r := range rows {
+		width = max(width, len(r[0]))
+	}
+	for _, r := range rows {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, r[0], r[1])
+	}
+}
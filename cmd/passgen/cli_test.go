@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testFlags struct {
+	input   string
+	length  int
+	verbose bool
+	all     bool
+}
+
+func newTestFlagSet(f *testFlags) *flagSet {
+	fs := newFlagSet("test")
+	fs.StringVar(&f.input, "input", "i", "TEXT", "", "Input")
+	fs.IntVar(&f.length, "length", "l", "NUM", 64, "Length")
+	fs.BoolVar(&f.verbose, "verbose", "v", "Verbose")
+	fs.BoolVar(&f.all, "all", "a", "All")
+	return fs
+}
+
+func TestFlagSet_Parse(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want testFlags
+		pos  []string
+	}{
+		{"defaults", nil, testFlags{length: 64}, nil},
+		{"long with space", []string{"--input", "x", "--length", "16"}, testFlags{input: "x", length: 16}, nil},
+		{"long with equals", []string{"--input=x=y", "--length=16"}, testFlags{input: "x=y", length: 16}, nil},
+		{"short with space", []string{"-i", "x", "-l", "16"}, testFlags{input: "x", length: 16}, nil},
+		{"short attached", []string{"-ix", "-l16"}, testFlags{input: "x", length: 16}, nil},
+		{"combined bools", []string{"-va"}, testFlags{length: 64, verbose: true, all: true}, nil},
+		{"combined bools with value", []string{"-val16"}, testFlags{length: 16, verbose: true, all: true}, nil},
+		{"bool with value", []string{"--verbose=false", "--all=true"}, testFlags{length: 64, all: true}, nil},
+		{"negative value", []string{"-l", "-1"}, testFlags{length: -1}, nil},
+		{"value starting with dash", []string{"--input", "-v"}, testFlags{input: "-v", length: 64}, nil},
+		{"interleaved positionals", []string{"a", "-v", "b"}, testFlags{length: 64, verbose: true}, []string{"a", "b"}},
+		{"double dash", []string{"-v", "--", "-a", "--input"}, testFlags{length: 64, verbose: true}, []string{"-a", "--input"}},
+		{"single dash is positional", []string{"-"}, testFlags{length: 64}, []string{"-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testFlags
+			fs := newTestFlagSet(&got)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.args, err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+			if !reflect.DeepEqual(fs.Args(), tt.pos) {
+				t.Errorf("Args() = %q, want %q", fs.Args(), tt.pos)
+			}
+		})
+	}
+}
+
+func TestFlagSet_ParseErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--bogus"}, "unknown flag: --bogus"},
+		{[]string{"-x"}, "unknown shorthand flag: -x"},
+		{[]string{"-vx"}, "unknown shorthand flag: -x in -vx"},
+		{[]string{"--input"}, "flag needs an argument: --input"},
+		{[]string{"-l"}, "flag needs an argument: -l"},
+		{[]string{"--length", "abc"}, `invalid value "abc" for --length`},
+		{[]string{"--verbose=maybe"}, `invalid value "maybe" for --verbose`},
+	}
+
+	for _, tt := range tests {
+		var f testFlags
+		err := newTestFlagSet(&f).Parse(tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestFlagSet_Help(t *testing.T) {
+	for _, args := range [][]string{{"-h"}, {"--help"}, {"-vh"}, {"-i", "x", "--help"}} {
+		var f testFlags
+		if err := newTestFlagSet(&f).Parse(args); !errors.Is(err, errHelp) {
+			t.Errorf("Parse(%q) error = %v, want errHelp", args, err)
+		}
+	}
+}
+
+func TestFlagSet_Changed(t *testing.T) {
+	var f testFlags
+	fs := newTestFlagSet(&f)
+	if err := fs.Parse([]string{"-l", "64"}); err != nil {
+		t.Fatal(err)
+	}
+	if !fs.Changed("length") {
+		t.Error("Changed(length) = false after explicit -l")
+	}
+	if fs.Changed("input") {
+		t.Error("Changed(input) = true without -i")
+	}
+}
+
+func TestFlagSet_PrintOptions(t *testing.T) {
+	var f testFlags
+	fs := newTestFlagSet(&f)
+	fs.lookupLong("all").hidden = true
+
+	var sb strings.Builder
+	fs.PrintOptions(&sb)
+	out := sb.String()
+
+	for _, want := range []string{"-i, --input TEXT", "-l, --length NUM", "(default: 64)", "-v, --verbose", "-h, --help"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PrintOptions() missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "--all") {
+		t.Error("PrintOptions() should skip hidden options")
+	}
+}
+
+func TestLookupCommand(t *testing.T) {
+	cmd, rest := lookupCommand([]string{"token", "verify", "abc"})
+	if cmd != tokenVerifyCommand || !reflect.DeepEqual(rest, []string{"abc"}) {
+		t.Errorf("lookupCommand(token verify abc) = %v, %q", cmd, rest)
+	}
+
+	cmd, rest = lookupCommand([]string{"token", "--prefix", "x"})
+	if cmd != tokenCommand || !reflect.DeepEqual(rest, []string{"--prefix", "x"}) {
+		t.Errorf("lookupCommand(token --prefix x) = %v, %q", cmd, rest)
+	}
+
+	if cmd, _ := lookupCommand([]string{"nope"}); cmd != nil {
+		t.Errorf("lookupCommand(nope) = %v, want nil", cmd)
+	}
+}
+
+func TestFlagSet_ApplyEnv(t *testing.T) {
+	var f testFlags
+	var salt string
+	fs := newTestFlagSet(&f)
+	fs.StringVar(&salt, "salt", "s", "TEXT", "", "Salt")
+	allowed := []string{"input", "length", "verbose"}
+	if err := fs.Parse([]string{"-l", "16"}); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"PASSGEN_INPUT":   "from-env",
+		"PASSGEN_LENGTH":  "32",
+		"PASSGEN_VERBOSE": "",
+		"PASSGEN_ALL":     "true",
+		"PASSGEN_SALT":    "secret",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	if err := fs.applyEnv(lookup, allowed); err != nil {
+		t.Fatal(err)
+	}
+
+	want := testFlags{input: "from-env", length: 16}
+	if f != want || salt != "" {
+		t.Errorf("after applyEnv() flags = %+v, salt = %q; want %+v and no salt", f, salt, want)
+	}
+	for long, source := range map[string]string{"input": "env", "length": "flag", "verbose": "", "all": "", "salt": ""} {
+		if got := fs.Source(long); got != source {
+			t.Errorf("Source(%s) = %q, want %q", long, got, source)
+		}
+	}
+
+	env["PASSGEN_VERBOSE"] = "maybe"
+	if err := newTestFlagSet(&f).applyEnv(lookup, allowed); err == nil || !strings.Contains(err.Error(), "PASSGEN_VERBOSE") {
+		t.Errorf("applyEnv() with an invalid value error = %v", err)
+	}
+}
+
+func TestFlagSet_StopAtArg(t *testing.T) {
+	var f testFlags
+	fs := newTestFlagSet(&f)
+	fs.stopAtArg = true
+	if err := fs.Parse([]string{"-v", "gen", "-l", "16", "--unknown"}); err != nil {
+		t.Fatal(err)
+	}
+	if !f.verbose || f.length != 64 || !reflect.DeepEqual(fs.Args(), []string{"gen", "-l", "16", "--unknown"}) {
+		t.Errorf("flags = %+v, args = %q; want parsing to stop at gen", f, fs.Args())
+	}
+}
+
+func TestLegacyArgs(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-input=x", "-salt=y"}, []string{"--input=x", "--salt=y"}},
+		{[]string{"-input", "x", "-length", "12", "-level", "low"}, []string{"--input", "x", "--length", "12", "--level", "low"}},
+		{[]string{"-i=x", "-l=12"}, []string{"--input=x", "--length=12"}},
+		{[]string{"-random-salt", "-i", "-salt"}, []string{"--random-salt", "-i", "-salt"}},
+		{[]string{"-i", "x", "-l16", "--salt", "y"}, []string{"-i", "x", "-l16", "--salt", "y"}},
+		{[]string{"-i", "x", "--", "-salt"}, []string{"-i", "x", "--", "-salt"}},
+	} {
+		got, err := legacyArgs(genCommand, tt.args)
+		if err != nil {
+			t.Errorf("legacyArgs(%q) error: %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("legacyArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	for _, args := range [][]string{{"-inptu=x"}, {"-x=1"}} {
+		if _, err := legacyArgs(genCommand, args); err == nil {
+			t.Errorf("legacyArgs(%q) error = nil, want error", args)
+		}
+	}
+}
+
+// runStdout runs passgen with args and returns what it printed on stdout.
+func runStdout(t *testing.T, args ...string) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = run(args)
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatalf("run(%q) error: %v", args, err)
+	}
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+// TestRun_LegacySingleDash checks that single-dash long flags still print
+// what the original flag-package parser printed.
+func TestRun_LegacySingleDash(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	out := runStdout(t, "-input=x", "-salt=y", "-fingerprint=none")
+	const want = "bLw2jyAxvsdrSrtGdB9f7PDU5ilQz1GbAP7aeIlha0vRiZQMSNdXxIt8Rb6EXz0e\n"
+	if out != want {
+		t.Errorf("passgen -input=x -salt=y printed %q, want %q", out, want)
+	}
+}
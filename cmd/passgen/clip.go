@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen/clipboard"
+)
+
+type clipOptions struct {
+	enabled bool
+	timeout time.Duration
+	backend string
+}
+
+func (c *clipOptions) register(fs *flagSet) {
+	fs.BoolVar(&c.enabled, "clip", "", "Copy to the clipboard instead of printing")
+	fs.DurationVar(&c.timeout, "clip-timeout", "", "DURATION", 45*time.Second, "Restore or clear the clipboard after DURATION (0 keeps it)")
+	fs.StringVar(&c.backend, "clip-backend", "", "NAME", "auto", "Clipboard backend: auto, wl-copy, xclip, xsel or osc52")
+}
+
+// deliver copies secret to the clipboard, then waits for the timeout or an
+// interrupt and puts back whatever the clipboard held before.
+func (c clipOptions) deliver(secret string) error {
+	cb, err := clipboard.New(c.backend)
+	if err != nil {
+		return err
+	}
+
+	previous, pasteErr := cb.Paste()
+	if err := cb.Copy(secret); err != nil {
+		return err
+	}
+
+	if c.timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "Copied to clipboard (%s).\n", cb.Name())
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Copied to clipboard (%s). Clearing in %s; press Ctrl-C to clear now.\n", cb.Name(), c.timeout)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-sigs:
+	}
+
+	if err := clipboard.Restore(cb, secret, previous, pasteErr == nil); err != nil {
+		return fmt.Errorf("restoring clipboard: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, "Clipboard restored.")
+	return nil
+}
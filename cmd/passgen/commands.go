@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// exitCode ends the process with the given status without printing an error;
+// commands return it after reporting the outcome themselves.
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("exit status %d", int(c))
+}
+
+const (
+	progName    = "passgen"
+	rootSummary = "Generate deterministic passwords, random strings and other secrets"
+)
+
+type command struct {
+	name     string
+	usage    string
+	summary  string
+	details  string
+	examples []example
+	env      []envVar
+	// envDefaults names the long options that default from
+	// PASSGEN_<OPTION>. See envGenerator for what never belongs here.
+	envDefaults []string
+	setup       func(fs *flagSet) func() error
+}
+
+// example is a full command line shown in help and the manual, after a
+// comment saying what it does.
+type example struct {
+	desc string
+	cmd  string
+}
+
+// envVar is an environment variable read by a command.
+type envVar struct {
+	name string
+	desc string
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		genCommand,
+		randomCommand,
+		verifyCommand,
+		recoveryCodesCommand,
+		tokenCommand,
+		tokenVerifyCommand,
+		uuidCommand,
+		batchCommand,
+		serveCommand,
+		rpcCommand,
+		tuiCommand,
+		agentCommand,
+		agentUnlockCommand,
+		agentLockCommand,
+		agentStatusCommand,
+		siteAddCommand,
+		siteListCommand,
+		siteShowCommand,
+		siteRmCommand,
+		exportCommand,
+		vaultInitCommand,
+		vaultAddCommand,
+		vaultRmCommand,
+		vaultUnlockCommand,
+		vaultPasswdCommand,
+		envCommand,
+		manCommand,
+		completionCommand,
+		versionCommand,
+	}
+}
+
+func lookupCommand(args []string) (*command, []string) {
+	if len(args) >= 2 {
+		for _, c := range commands {
+			if c.name == args[0]+" "+args[1] {
+				return c, args[2:]
+			}
+		}
+	}
+	if len(args) >= 1 {
+		for _, c := range commands {
+			if c.name == args[0] {
+				return c, args[1:]
+			}
+		}
+	}
+	return nil, args
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		printRootHelp(os.Stderr)
+		return exitCode(1)
+	}
+
+	switch args[0] {
+	case "-h", "--help":
+		printRootHelp(os.Stdout)
+		return nil
+	case "--version":
+		return runCommand(versionCommand, args[1:])
+	case "help":
+		return runHelp(args[1:])
+	case "__complete":
+		return runComplete(args[1:])
+	}
+
+	if strings.HasPrefix(args[0], "-") {
+		return runLegacy(args)
+	}
+
+	cmd, rest := lookupCommand(args)
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q (run '%s --help' for a list)", args[0], progName)
+	}
+	return runCommand(cmd, rest)
+}
+
+// runLegacy keeps the original flag-only invocations working: plain options
+// mean "gen", and --gen-random selects "random". The original parser also
+// took long flags with a single dash, as in -input=github.com.
+func runLegacy(args []string) error {
+	rest := make([]string, 0, len(args))
+	random := false
+	for i, a := range args {
+		if a == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		switch a {
+		case "--gen-random", "-gen-random":
+			random = true
+			continue
+		case "--version", "-version":
+			return runCommand(versionCommand, nil)
+		}
+		rest = append(rest, a)
+	}
+
+	cmd := genCommand
+	if random {
+		cmd = randomCommand
+	}
+	rest, err := legacyArgs(cmd, rest)
+	if err != nil {
+		return err
+	}
+	return runCommand(cmd, rest)
+}
+
+// legacyArgs rewrites the single-dash forms the flag package accepted for the
+// options of cmd: -input and -input=x become --input and --input=x, and -i=x
+// becomes -i x. Values of options are left alone, and other single-dash
+// arguments such as -l16 or -ri are short flags as before.
+func legacyArgs(cmd *command, args []string) ([]string, error) {
+	fs := newFlagSet(cmd.name)
+	cmd.setup(fs)
+
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...), nil
+		}
+		if len(arg) < 2 || arg[0] != '-' || arg[1] == '-' {
+			out = append(out, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg[1:], "=")
+		var o *option
+		switch {
+		case len(name) == 1 && hasValue:
+			if o = fs.lookupShort(name); o == nil {
+				return nil, fmt.Errorf("unknown shorthand flag: -%s in %s", name, arg)
+			}
+			arg = "--" + o.long + "=" + value
+		case len(name) > 1:
+			if o = fs.lookupLong(name); o != nil {
+				arg = "-" + arg
+			} else if hasValue {
+				return nil, fmt.Errorf("unknown flag: -%s (long flags take two dashes)", name)
+			}
+		case len(name) == 1:
+			o = fs.lookupShort(name)
+		}
+		out = append(out, arg)
+
+		// Keep the value of an option that takes one, even if it starts
+		// with a dash.
+		if o != nil && o.kind != boolFlag && !hasValue && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out, nil
+}
+
+func runCommand(cmd *command, args []string) error {
+	fs := newFlagSet(cmd.name)
+	action := cmd.setup(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, errHelp) {
+			printCommandHelp(os.Stdout, cmd)
+			return nil
+		}
+		return fmt.Errorf("%v (run '%s %s --help' for usage)", err, progName, cmd.name)
+	}
+	if err := fs.applyEnv(os.LookupEnv, cmd.envDefaults); err != nil {
+		return err
+	}
+
+	return action()
+}
+
+func runHelp(args []string) error {
+	if len(args) == 0 {
+		printRootHelp(os.Stdout)
+		return nil
+	}
+
+	cmd, rest := lookupCommand(args)
+	if cmd == nil || len(rest) > 0 {
+		return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+	}
+	printCommandHelp(os.Stdout, cmd)
+	return nil
+}
+
+func printRootHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [OPTIONS]\n", progName)
+	fmt.Fprintln(w, rootSummary)
+	fmt.Fprintln(w, "\nCommands:")
+
+	width := 0
+	for _, c := range commands {
+		width = max(width, len(c.name))
+	}
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, c.name, c.summary)
+	}
+
+	fmt.Fprintf(w, "\nRunning %s with options but no command is the same as '%s gen'.\n", progName, progName)
+	fmt.Fprintf(w, "Run '%s <command> --help' for the options of a command.\n", progName)
+}
+
+func printCommandHelp(w io.Writer, cmd *command) {
+	fs := newFlagSet(cmd.name)
+	cmd.setup(fs)
+
+	fmt.Fprintf(w, "Usage: %s %s %s\n", progName, cmd.name, cmd.usage)
+	fmt.Fprintln(w, cmd.summary)
+	if cmd.details != "" {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(cmd.details))
+	}
+	fmt.Fprintln(w, "\nOptions:")
+	fs.PrintOptions(w)
+
+	if len(cmd.examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for i, e := range cmd.examples {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "  # %s\n  %s\n", e.desc, e.cmd)
+		}
+	}
+
+	if env := commandEnv(cmd); len(env) > 0 {
+		fmt.Fprintln(w, "\nEnvironment:")
+		width := 0
+		for _, e := range env {
+			width = max(width, len(e.name))
+		}
+		for _, e := range env {
+			fmt.Fprintf(w, "  %-*s  %s\n", width, e.name, e.desc)
+		}
+	}
+}
+
+// commandEnv returns the variables in the env field of cmd followed by those
+// of its envDefaults options.
+func commandEnv(cmd *command) []envVar {
+	fs := newFlagSet(cmd.name)
+	cmd.setup(fs)
+
+	env := slices.Clone(cmd.env)
+	for _, o := range fs.options {
+		if slices.Contains(cmd.envDefaults, o.long) {
+			env = append(env, envVar{envName(o), "Default for --" + o.long})
+		}
+	}
+	return env
+}
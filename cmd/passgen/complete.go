@@ -0,0 +1,433 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/clipboard"
+	"github.com/zapsaang/pass-gen/pkg/passgen/export"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+// The last line printed by __complete tells the shell script whether to fall
+// back to file name completion.
+const (
+	completeNoFiles = ":0"
+	completeFiles   = ":1"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+type candidate struct {
+	value string
+	desc  string
+}
+
+var completionCommand = &command{
+	name:    "completion",
+	usage:   "SHELL",
+	summary: "Print a shell completion script for bash, zsh or fish",
+	details: `Load completions for the current session, or save them where your shell
+looks for completion scripts:
+
+  bash:  source <(passgen completion bash)
+  zsh:   passgen completion zsh > "${fpath[1]}/_passgen"
+  fish:  passgen completion fish > ~/.config/fish/completions/passgen.fish`,
+	setup: func(fs *flagSet) func() error {
+		return func() error {
+			if len(fs.Args()) != 1 {
+				return fmt.Errorf("expected one shell: %s", strings.Join(completionShells, ", "))
+			}
+			switch fs.Args()[0] {
+			case "bash":
+				fmt.Print(bashCompletion)
+			case "zsh":
+				fmt.Print(zshCompletion)
+			case "fish":
+				fmt.Print(fishCompletion)
+			default:
+				return fmt.Errorf("unsupported shell %q (use %s)", fs.Args()[0], strings.Join(completionShells, ", "))
+			}
+			return nil
+		}
+	},
+}
+
+// runComplete implements the hidden __complete command used by the shell
+// scripts. Its arguments are the words after the program name, the last one
+// being the word under the cursor. It prints one candidate per line, with an
+// optional tab-separated description, followed by a directive line.
+func runComplete(args []string) error {
+	words, current := []string{}, ""
+	if len(args) > 0 {
+		words, current = args[:len(args)-1], args[len(args)-1]
+	}
+
+	candidates, files := complete(words, current)
+	for _, c := range candidates {
+		if c.desc != "" {
+			fmt.Printf("%s\t%s\n", c.value, c.desc)
+		} else {
+			fmt.Println(c.value)
+		}
+	}
+	if files {
+		fmt.Println(completeFiles)
+	} else {
+		fmt.Println(completeNoFiles)
+	}
+	return nil
+}
+
+func complete(words []string, current string) ([]candidate, bool) {
+	if len(words) == 0 && !strings.HasPrefix(current, "-") {
+		return filterCandidates(commandWords(""), current), false
+	}
+
+	switch {
+	case len(words) > 0 && words[0] == "help":
+		if len(words) == 1 {
+			return filterCandidates(commandWords(""), current), false
+		}
+		if len(words) == 2 {
+			return filterCandidates(commandWords(words[1]), current), false
+		}
+		return nil, false
+	case len(words) > 0 && words[0] == "completion":
+		if len(words) == 1 {
+			return filterCandidates(plainCandidates(completionShells...), current), false
+		}
+		return nil, false
+	}
+
+	if len(words) == 1 && !strings.HasPrefix(current, "-") {
+		if sub := commandWords(words[0]); len(sub) > 0 {
+			return filterCandidates(sub, current), false
+		}
+	}
+
+	cmd, rest := genCommand, words
+	if len(words) > 0 && !strings.HasPrefix(words[0], "-") {
+		cmd, rest = lookupCommand(words)
+		if cmd == nil {
+			return nil, false
+		}
+	}
+
+	fs := newFlagSet(cmd.name)
+	cmd.setup(fs)
+
+	if o := pendingValue(fs, rest); o != nil {
+		values, files := flagValues(cmd.name, o, words)
+		return filterCandidates(values, current), files
+	}
+
+	if strings.HasPrefix(current, "--") {
+		if name, _, ok := strings.Cut(current[2:], "="); ok {
+			o := fs.lookupLong(name)
+			if o == nil || o.kind == boolFlag {
+				return nil, false
+			}
+			values, files := flagValues(cmd.name, o, words)
+			for i := range values {
+				values[i].value = "--" + name + "=" + values[i].value
+			}
+			return filterCandidates(values, current), files
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		var flags []candidate
+		for _, o := range fs.options {
+			if !o.hidden {
+				flags = append(flags, candidate{"--" + o.long, o.usage})
+			}
+		}
+		flags = append(flags, candidate{"--help", "Show this help message"})
+		if len(words) == 0 {
+			flags = append(flags, candidate{"--version", "Print version information"})
+		}
+		return filterCandidates(flags, current), false
+	}
+
+	switch cmd.name {
+	case "gen", "site show", "site rm", "export":
+		return filterCandidates(siteCandidates(words), current), false
+	case "batch":
+		return nil, true
+	}
+	return nil, false
+}
+
+// commandWords returns the first words of the command names, or the second
+// words of the commands in group.
+func commandWords(group string) []candidate {
+	var out []candidate
+	seen := map[string]bool{}
+	for _, c := range commands {
+		first, second, _ := strings.Cut(c.name, " ")
+		word := first
+		if group != "" {
+			if first != group || second == "" {
+				continue
+			}
+			word = second
+		}
+
+		desc := c.summary
+		if group == "" && second != "" {
+			desc = "Subcommands: " + strings.Join(subcommandNames(first), ", ")
+		}
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		out = append(out, candidate{word, desc})
+	}
+	if group == "" {
+		out = append(out, candidate{"help", "Show help for a command"})
+	}
+	return out
+}
+
+func subcommandNames(group string) []string {
+	var names []string
+	for _, c := range commands {
+		if first, second, ok := strings.Cut(c.name, " "); ok && first == group {
+			names = append(names, second)
+		}
+	}
+	return names
+}
+
+// pendingValue returns the option whose value is being typed, when the last
+// word is a flag that takes a separate argument.
+func pendingValue(fs *flagSet, words []string) *option {
+	if len(words) == 0 {
+		return nil
+	}
+	prev := words[len(words)-1]
+
+	if strings.HasPrefix(prev, "--") {
+		if strings.Contains(prev, "=") {
+			return nil
+		}
+		if o := fs.lookupLong(prev[2:]); o != nil && o.kind != boolFlag {
+			return o
+		}
+		return nil
+	}
+
+	if len(prev) < 2 || prev[0] != '-' {
+		return nil
+	}
+	for j := 1; j < len(prev); j++ {
+		o := fs.lookupShort(prev[j : j+1])
+		if o == nil {
+			return nil
+		}
+		if o.kind != boolFlag {
+			if j == len(prev)-1 {
+				return o
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func flagValues(cmd string, o *option, words []string) ([]candidate, bool) {
+	switch o.long {
+	case "level":
+		return []candidate{
+			{string(passgen.LevelLow), "Lowercase letters"},
+			{string(passgen.LevelMedium), "Letters and digits"},
+			{string(passgen.LevelStrong), "Letters, digits and symbols"},
+		}, false
+	case "encoding":
+		var out []candidate
+		for _, e := range passgen.Encodings {
+			out = append(out, candidate{value: string(e)})
+		}
+		return out, false
+	case "fingerprint":
+		return plainCandidates(string(passgen.FingerprintWords), string(passgen.FingerprintEmoji),
+			string(passgen.FingerprintIdenticon), "none"), false
+	case "format":
+		switch cmd {
+		case batchCommand.name:
+			return plainCandidates("jsonl", "csv", "dir"), false
+		case manCommand.name:
+			return plainCandidates("roff", "markdown"), false
+		case exportCommand.name:
+			var out []candidate
+			for _, f := range export.Formats {
+				out = append(out, candidate{value: string(f)})
+			}
+			return out, false
+		}
+		return plainCandidates("text", "json", "env"), false
+	case "manifest-format":
+		return plainCandidates("csv", "jsonl"), false
+	case "clip-backend":
+		return plainCandidates(append([]string{"auto"}, clipboard.Backends()...)...), false
+	case "kind":
+		return plainCandidates("salt", "input"), false
+	case "version":
+		return plainCandidates("4", "5", "7", "8"), false
+	case "namespace":
+		return plainCandidates("dns", "url", "oid", "x500"), false
+	case "algo-version":
+		return plainCandidates(strconv.Itoa(passgen.AlgorithmVersion)), false
+	case "qr-level":
+		return plainCandidates("L", "M", "Q", "H"), false
+	case "profile":
+		return profileCandidates(words), false
+	case "config", "store", "vault", "output", "socket", "token-file", "qr-png":
+		return nil, true
+	}
+	return nil, false
+}
+
+func profileCandidates(words []string) []candidate {
+	path := wordValue(words, "config")
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	cfg, err := loadConfig(path, false)
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(cfg.profiles))
+	for name := range cfg.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return plainCandidates(names...)
+}
+
+func siteCandidates(words []string) []candidate {
+	path := wordValue(words, "store")
+	if path == "" {
+		path = site.DefaultPath()
+	}
+	st, err := site.Open(path)
+	if err != nil {
+		return nil
+	}
+
+	var out []candidate
+	for _, s := range st.List() {
+		out = append(out, candidate{s.Name, s.Username})
+	}
+	return out
+}
+
+// wordValue returns the value given to --long in words, if any.
+func wordValue(words []string, long string) string {
+	for i, w := range words {
+		if v, ok := strings.CutPrefix(w, "--"+long+"="); ok {
+			return v
+		}
+		if w == "--"+long && i+1 < len(words) {
+			return words[i+1]
+		}
+	}
+	return ""
+}
+
+func plainCandidates(values ...string) []candidate {
+	out := make([]candidate, len(values))
+	for i, v := range values {
+		out[i] = candidate{value: v}
+	}
+	return out
+}
+
+func filterCandidates(candidates []candidate, prefix string) []candidate {
+	var out []candidate
+	for _, c := range candidates {
+		if strings.HasPrefix(c.value, prefix) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+const bashCompletion = `# bash completion for passgen
+_passgen() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    local -a out
+    out=($(passgen __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null)) || return
+    [[ ${#out[@]} -eq 0 ]] && return
+
+    local directive="${out[${#out[@]}-1]}"
+    unset 'out[${#out[@]}-1]'
+
+    if [[ $directive == ":1" ]]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+        return
+    fi
+    COMPREPLY=("${out[@]%%$'\t'*}")
+}
+complete -o nosort -F _passgen passgen 2>/dev/null || complete -F _passgen passgen
+`
+
+const zshCompletion = `#compdef passgen
+# zsh completion for passgen
+
+_passgen() {
+    local -a lines items
+    local directive line name desc
+
+    lines=("${(@f)$(passgen __complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}")
+    directive=${lines[-1]}
+    lines=("${(@)lines[1,-2]}")
+
+    if [[ $directive == ":1" ]]; then
+        _files
+        return
+    fi
+
+    for line in "${lines[@]}"; do
+        [[ -z $line ]] && continue
+        name=${line%%$'\t'*}
+        desc=""
+        [[ $line == *$'\t'* ]] && desc=${line#*$'\t'}
+        items+=("${name//:/\\:}${desc:+:$desc}")
+    done
+    _describe -t passgen passgen items
+}
+
+if [[ $funcstack[1] == _passgen ]]; then
+    _passgen "$@"
+else
+    compdef _passgen passgen
+fi
+`
+
+const fishCompletion = `# fish completion for passgen
+function __passgen_complete
+    set -l tokens (commandline -opc)
+    set -l current (commandline -ct)
+    set -l out (passgen __complete $tokens[2..-1] "$current" 2>/dev/null)
+    or return
+
+    set -l directive $out[-1]
+    set -e out[-1]
+    if test "$directive" = ":1"
+        __fish_complete_path "$current"
+        return
+    end
+    for line in $out
+        echo $line
+    end
+end
+
+complete -c passgen -f -a '(__passgen_complete)'
+`
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+func candidateValues(cs []candidate) []string {
+	var out []string
+	for _, c := range cs {
+		out = append(out, c.value)
+	}
+	return out
+}
+
+func TestComplete(t *testing.T) {
+	store := filepath.Join(t.TempDir(), "sites.json")
+	st, _ := site.Open(store)
+	for _, name := range []string{"github.com", "gitlab.com", "example.org"} {
+		if err := st.Put(site.Site{Name: name, Length: 16, Level: "medium", Counter: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		words     []string
+		current   string
+		want      []string
+		wantFiles bool
+	}{
+		{"commands", nil, "ve", []string{"verify", "version"}, false},
+		{"group", []string{"site"}, "", []string{"add", "list", "show", "rm"}, false},
+		{"help group", []string{"help", "vault"}, "p", []string{"passwd"}, false},
+		{"long flags", []string{"gen"}, "--le", []string{"--length", "--level"}, false},
+		{"legacy flags", nil, "--vers", []string{"--version"}, false},
+		{"level value", []string{"gen", "-L"}, "", []string{"low", "medium", "strong"}, false},
+		{"clustered short", []string{"gen", "-pL"}, "s", []string{"strong"}, false},
+		{"legacy level value", []string{"-i", "x", "--level"}, "m", []string{"medium"}, false},
+		{"equals form", []string{"gen"}, "--format=j", []string{"--format=json"}, false},
+		{"bool flag takes no value", []string{"gen", "--clip"}, "--clip-t", []string{"--clip-timeout"}, false},
+		{"file flag", []string{"gen", "--config"}, "", nil, true},
+		{"uuid version", []string{"uuid", "-v"}, "", []string{"4", "5", "7", "8"}, false},
+		{"completion shells", []string{"completion"}, "", []string{"bash", "zsh", "fish"}, false},
+		{"site names", []string{"gen", "--store", store}, "git", []string{"github.com", "gitlab.com"}, false},
+		{"site show", []string{"site", "show", "--store=" + store}, "", []string{"example.org", "github.com", "gitlab.com"}, false},
+		{"unknown command", []string{"nope"}, "", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, files := complete(tt.words, tt.current)
+			if values := candidateValues(got); !reflect.DeepEqual(values, tt.want) {
+				t.Errorf("complete(%q, %q) = %q, want %q", tt.words, tt.current, values, tt.want)
+			}
+			if files != tt.wantFiles {
+				t.Errorf("complete(%q, %q) files = %v, want %v", tt.words, tt.current, files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+// The word being completed must reach passgen as exactly one argument, even
+// when it is empty or contains spaces.
+func TestCompletionScripts_QuoteCurrent(t *testing.T) {
+	for _, tt := range []struct {
+		shell, script, want string
+	}{
+		{"bash", bashCompletion, `__complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur"`},
+		{"zsh", zshCompletion, `__complete "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}"`},
+		{"fish", fishCompletion, `__complete $tokens[2..-1] "$current"`},
+	} {
+		if !strings.Contains(tt.script, tt.want) {
+			t.Errorf("%s completion does not contain %s", tt.shell, tt.want)
+		}
+	}
+	if strings.Contains(fishCompletion, "__fish_complete_path $current") {
+		t.Error("fish completion passes $current unquoted to __fish_complete_path")
+	}
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// configKeys maps keys accepted in the configuration file to the long flag
+// they provide a default for.
+var configKeys = map[string]string{
+	"level":        "level",
+	"length":       "length",
+	"encoding":     "encoding",
+	"algo_version": "algo-version",
+	"fingerprint":  "fingerprint",
+}
+
+type configFile struct {
+	path     string
+	defaults map[string]string
+	profiles map[string]map[string]string
+}
+
+func defaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "passgen", "config")
+}
+
+// loadConfig reads the configuration file at path. A missing file yields an
+// empty configuration unless required is set.
+func loadConfig(path string, required bool) (*configFile, error) {
+	cfg := &configFile{
+		path:     path,
+		defaults: map[string]string{},
+		profiles: map[string]map[string]string{},
+	}
+	if path == "" {
+		return cfg, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) && !required {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := checkConfigPerm(f); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(f); err != nil {
+		return nil, err
+	}
+
+	data := bytes.TrimSpace(buf.Bytes())
+	if len(data) > 0 && data[0] == '{' {
+		err = cfg.parseJSON(data)
+	} else {
+		err = cfg.parseTOML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+func checkConfigPerm(f *os.File) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("refusing to load %s: file is group- or world-writable (run chmod go-w)", f.Name())
+	}
+	return nil
+}
+
+// parseTOML accepts the subset of TOML that passgen needs: top-level keys
+// (or a [defaults] table) set defaults, and [profile.NAME] tables define
+// profiles. Values are quoted strings or bare integers and words.
+func (c *configFile) parseTOML(data []byte) error {
+	section := c.defaults
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %d: malformed table header", lineNo)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			switch {
+			case name == "defaults":
+				section = c.defaults
+			case strings.HasPrefix(name, "profile.") || strings.HasPrefix(name, "profiles."):
+				_, profile, _ := strings.Cut(name, ".")
+				profile = strings.Trim(profile, `"`)
+				if profile == "" {
+					return fmt.Errorf("line %d: empty profile name", lineNo)
+				}
+				if _, ok := c.profiles[profile]; ok {
+					return fmt.Errorf("line %d: duplicate profile %q", lineNo, profile)
+				}
+				section = map[string]string{}
+				c.profiles[profile] = section
+			default:
+				return fmt.Errorf("line %d: unknown table [%s]", lineNo, name)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return fmt.Errorf("line %d: malformed string for %s", lineNo, key)
+			}
+			value = unquoted
+		}
+
+		if err := setConfigKey(section, key, value); err != nil {
+			return fmt.Errorf("line %d: %v", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func (c *configFile) parseJSON(data []byte) error {
+	var raw struct {
+		Defaults map[string]any            `json:"defaults"`
+		Profiles map[string]map[string]any `json:"profiles"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	load := func(dst map[string]string, src map[string]any) error {
+		for k, v := range src {
+			var s string
+			switch x := v.(type) {
+			case string:
+				s = x
+			case float64:
+				s = strconv.FormatFloat(x, 'f', -1, 64)
+			default:
+				return fmt.Errorf("%s: value must be a string or number", k)
+			}
+			if err := setConfigKey(dst, k, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := load(c.defaults, raw.Defaults); err != nil {
+		return fmt.Errorf("defaults: %v", err)
+	}
+	for name, values := range raw.Profiles {
+		p := map[string]string{}
+		if err := load(p, values); err != nil {
+			return fmt.Errorf("profile %s: %v", name, err)
+		}
+		c.profiles[name] = p
+	}
+	return nil
+}
+
+func setConfigKey(section map[string]string, key, value string) error {
+	if _, ok := configKeys[key]; !ok {
+		return fmt.Errorf("unknown key %q (use %s)", key, strings.Join(sortedConfigKeys(), ", "))
+	}
+	if _, dup := section[key]; dup {
+		return fmt.Errorf("duplicate key %q", key)
+	}
+	section[key] = value
+	return nil
+}
+
+func sortedConfigKeys() []string {
+	keys := make([]string, 0, len(configKeys))
+	for k := range configKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// apply fills options that were not set on the command line, first from the
+// named profile and then from the file defaults.
+func (c *configFile) apply(fs *flagSet, profile string) error {
+	if profile != "" {
+		values, ok := c.profiles[profile]
+		if !ok {
+			return fmt.Errorf("profile %q not found in %s", profile, c.path)
+		}
+		if err := fs.applyLayer(values, "profile "+profile); err != nil {
+			return err
+		}
+	}
+	return fs.applyLayer(c.defaults, "config")
+}
+
+type configOptions struct {
+	path    string
+	profile string
+}
+
+func (o *configOptions) register(fs *flagSet) {
+	fs.StringVar(&o.path, "config", "", "PATH", "", "Configuration file (default: $XDG_CONFIG_HOME/passgen/config)")
+	fs.StringVar(&o.profile, "profile", "P", "NAME", "", "Use a named profile from the configuration file")
+}
+
+func (o configOptions) apply(fs *flagSet) error {
+	path := o.path
+	if path == "" {
+		path = defaultConfigPath()
+	}
+
+	cfg, err := loadConfig(path, o.path != "")
+	if err != nil {
+		return err
+	}
+	return cfg.apply(fs, o.profile)
+}
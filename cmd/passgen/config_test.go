@@ -0,0 +1,168 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_Formats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"toml", `
+# defaults
+length = 20
+level = "strong"
+
+[profile.work]
+level = low   # bare word
+algo_version = 1
+`},
+		{"toml defaults table", `
+[defaults]
+length = 20
+level = "strong"
+
+[profile.work]
+level = "low"
+algo_version = 1
+`},
+		{"json", `{"defaults": {"length": 20, "level": "strong"}, "profiles": {"work": {"level": "low", "algo_version": 1}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadConfig(writeConfig(t, tt.content, 0o600), true)
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if cfg.defaults["length"] != "20" || cfg.defaults["level"] != "strong" {
+				t.Errorf("defaults = %v", cfg.defaults)
+			}
+			work := cfg.profiles["work"]
+			if work["level"] != "low" || work["algo_version"] != "1" {
+				t.Errorf("profile work = %v", work)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "colour = 1\n", `line 1: unknown key "colour"`},
+		{"unknown table", "[server]\n", "line 1: unknown table"},
+		{"missing equals", "length 20\n", "line 1: expected key = value"},
+		{"duplicate key", "length = 1\nlength = 2\n", `line 2: duplicate key "length"`},
+		{"bad string", `level = "strong` + "\n", "line 1: malformed string"},
+		{"json unknown key", `{"defaults": {"salt": "x"}}`, `unknown key "salt"`},
+		{"json bad value", `{"defaults": {"length": true}}`, "must be a string or number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(writeConfig(t, tt.content, 0o600), true)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("loadConfig() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Permissions(t *testing.T) {
+	for _, perm := range []os.FileMode{0o620, 0o602} {
+		path := writeConfig(t, "length = 20\n", perm)
+		if _, err := loadConfig(path, true); err == nil || !strings.Contains(err.Error(), "writable") {
+			t.Errorf("loadConfig() with mode %o error = %v, want refusal", perm, err)
+		}
+	}
+
+	if _, err := loadConfig(writeConfig(t, "length = 20\n", 0o644), true); err != nil {
+		t.Errorf("loadConfig() with mode 644 error = %v", err)
+	}
+}
+
+func TestLoadConfig_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+	if _, err := loadConfig(path, false); err != nil {
+		t.Errorf("loadConfig() of a missing default file error = %v", err)
+	}
+	if _, err := loadConfig(path, true); err == nil {
+		t.Error("loadConfig() of a missing explicit file should fail")
+	}
+}
+
+func TestConfigFile_Apply(t *testing.T) {
+	path := writeConfig(t, `
+length = 20
+level = "strong"
+
+[profile.work]
+level = "low"
+`, 0o600)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantLevel  string
+		wantLength int
+		wantSource string
+	}{
+		{"file defaults", nil, "strong", 20, "config"},
+		{"profile over defaults", []string{"--profile", "work"}, "low", 20, "profile work"},
+		{"flags over profile", []string{"-P", "work", "-L", "medium"}, "medium", 20, "flag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var level string
+			var length int
+			var config configOptions
+			fs := newFlagSet("test")
+			fs.StringVar(&level, "level", "L", "LEVEL", "medium", "Level")
+			fs.IntVar(&length, "length", "l", "NUM", 64, "Length")
+			config.register(fs)
+
+			if err := fs.Parse(append([]string{"--config", path}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			if err := config.apply(fs); err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			if level != tt.wantLevel || length != tt.wantLength {
+				t.Errorf("level, length = %q, %d; want %q, %d", level, length, tt.wantLevel, tt.wantLength)
+			}
+			if got := fs.lookupLong("level").source; got != tt.wantSource {
+				t.Errorf("level source = %q, want %q", got, tt.wantSource)
+			}
+		})
+	}
+
+	var config configOptions
+	fs := newFlagSet("test")
+	config.register(fs)
+	if err := fs.Parse([]string{"--config", path, "--profile", "home"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.apply(fs); err == nil || !strings.Contains(err.Error(), `profile "home" not found`) {
+		t.Errorf("apply() with unknown profile error = %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Environment variables read by passgen. Commands list the ones they use in
+// their env field; environment orders them for the manual.
+var (
+	envOptions    = envVar{envPrefix + "<OPTION>", "Default for a long option that the command lists under Environment, e.g. PASSGEN_LENGTH for --length; flags win, and 'passgen env' shows the result"}
+	envSalt       = envVar{"PASSGEN_SALT", "Salt used when none is given with --salt, --salt-fd or --salt-ref"}
+	envAgentSock  = envVar{agentSockEnv, "Socket of the agent started by 'passgen agent', asked for the salt when no other salt is given"}
+	envServeToken = envVar{"PASSGEN_SERVE_TOKEN", "Bearer token for 'passgen serve' when --token-file and --token-fd are not given"}
+	envConfigHome = envVar{"XDG_CONFIG_HOME", "Base directory of the config file (default: ~/.config)"}
+	envDataHome   = envVar{"XDG_DATA_HOME", "Base directory of the site store and the vault (default: ~/.local/share)"}
+	envRuntimeDir = envVar{"XDG_RUNTIME_DIR", "Directory of the default agent socket"}
+	envNoColor    = envVar{"NO_COLOR", "Disable colour in identicon fingerprints"}
+
+	environment = []envVar{envOptions, envSalt, envAgentSock, envServeToken, envConfigHome, envDataHome, envRuntimeDir, envNoColor}
+)
+
+// files lists the paths passgen reads and writes, for the manual.
+var files = [][2]string{
+	{"$XDG_CONFIG_HOME/passgen/config", "Defaults and named profiles (--config)"},
+	{"$XDG_DATA_HOME/passgen/sites.json", "Site store with the generation parameters of each site (--store)"},
+	{"$XDG_DATA_HOME/passgen/vault", "Encrypted vault of salts and inputs (--vault)"},
+	{"$XDG_RUNTIME_DIR/passgen/agent.sock", "Socket of the agent (--socket)"},
+}
+
+const exitStatus = "0 on success and 1 on an error. verify, token verify and agent status also exit 1 when the check fails."
+
+var manCommand = &command{
+	name:    "man",
+	usage:   "[OPTIONS]",
+	summary: "Print the reference manual as a roff man page or Markdown",
+	details: "The manual is rendered from the same descriptions as --help. Release packages\ninstall the man page as passgen(1).",
+	examples: []example{
+		{"Read the manual without installing it", "passgen man | man -l -"},
+		{"Write the Markdown reference", "passgen man --format markdown > passgen.md"},
+	},
+	setup: func(fs *flagSet) func() error {
+		var format string
+		fs.StringVar(&format, "format", "", "FMT", "roff", "Output format: roff or markdown")
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			switch format {
+			case "roff":
+				writeMan(os.Stdout)
+			case "markdown":
+				writeMarkdown(os.Stdout)
+			default:
+				return fmt.Errorf("unknown format %q (want roff or markdown)", format)
+			}
+			return nil
+		}
+	},
+}
+
+// textBlock is a run of lines from a details string: prose to be filled, or
+// an indented table or command listing to be kept as is.
+type textBlock struct {
+	pre   bool
+	lines []string
+}
+
+// splitDetails breaks details into paragraphs and indented blocks.
+func splitDetails(details string) []textBlock {
+	var blocks []textBlock
+	var cur *textBlock
+	for _, line := range strings.Split(strings.TrimSpace(details), "\n") {
+		if line == "" {
+			cur = nil
+			continue
+		}
+		pre := strings.HasPrefix(line, "  ")
+		if cur == nil || cur.pre != pre {
+			blocks = append(blocks, textBlock{pre: pre})
+			cur = &blocks[len(blocks)-1]
+		}
+		if pre {
+			line = line[2:]
+		}
+		cur.lines = append(cur.lines, line)
+	}
+	return blocks
+}
+
+// commandRows returns the option rows of cmd.
+func commandRows(cmd *command) [][2]string {
+	fs := newFlagSet(cmd.name)
+	cmd.setup(fs)
+	return fs.optionRows()
+}
+
+// roff escapes s for a roff text line. With code set, hyphens become minus
+// signs so that options can be searched for and copied.
+func roff(s string, code bool) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	if code {
+		s = strings.ReplaceAll(s, "-", `\-`)
+	}
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+func writeMan(w io.Writer) {
+	// The build date is RFC 3339; the manual shows only the day.
+	day := ""
+	if len(date) >= 10 && date != "unknown" {
+		day = date[:10]
+	}
+	fmt.Fprintf(w, ".TH PASSGEN 1 %q %q \"User Commands\"\n", day, progName+" "+version)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintf(w, "%s \\- %s\n", progName, strings.ToLower(rootSummary[:1])+rootSummary[1:])
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B %s\n.I command\n[\\fIoptions\\fR]\n", progName)
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintf(w, "%s.\n", rootSummary)
+	fmt.Fprintf(w, ".PP\nRunning %s with options but no command is the same as\n.BR \"%s gen\" .\n", progName, progName)
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, ".SS %s\n", roff(strings.TrimSpace(progName+" "+cmd.name+" "+cmd.usage), true))
+		fmt.Fprintln(w, roff(cmd.summary+".", false))
+		for _, b := range splitDetails(cmd.details) {
+			if b.pre {
+				fmt.Fprintln(w, ".PP\n.RS 4\n.nf")
+				for _, line := range b.lines {
+					fmt.Fprintln(w, roff(line, true))
+				}
+				fmt.Fprintln(w, ".fi\n.RE")
+				continue
+			}
+			fmt.Fprintln(w, ".PP")
+			for _, line := range b.lines {
+				fmt.Fprintln(w, roff(line, false))
+			}
+		}
+		fmt.Fprintln(w, ".PP\nOptions:")
+		for _, r := range commandRows(cmd) {
+			fmt.Fprintf(w, ".TP\n.B %s\n%s\n", roff(strings.TrimSpace(r[0]), true), roff(r[1], false))
+		}
+		if len(cmd.examples) > 0 {
+			fmt.Fprintln(w, ".PP\nExamples:\n.PP\n.RS 4\n.nf")
+			for i, e := range cmd.examples {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "# %s\n%s\n", roff(e.desc, false), roff(e.cmd, true))
+			}
+			fmt.Fprintln(w, ".fi\n.RE")
+		}
+	}
+
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	for _, e := range environment {
+		fmt.Fprintf(w, ".TP\n.B %s\n%s\n", e.name, roff(e.desc, false))
+	}
+	fmt.Fprintln(w, ".SH FILES")
+	for _, f := range files {
+		fmt.Fprintf(w, ".TP\n.I %s\n%s\n", roff(f[0], false), roff(f[1], false))
+	}
+	fmt.Fprintln(w, ".SH EXIT STATUS")
+	fmt.Fprintln(w, roff(exitStatus, false))
+}
+
+// markdownCell escapes s for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# %s\n\n%s.\n\n", progName, rootSummary)
+	fmt.Fprintf(w, "Running `%s` with options but no command is the same as `%s gen`.\n", progName, progName)
+	fmt.Fprintln(w, "This reference is generated by `passgen man --format markdown`.")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\n## %s %s\n\n", progName, cmd.name)
+		fmt.Fprintf(w, "```\n%s\n```\n\n", strings.TrimSpace(progName+" "+cmd.name+" "+cmd.usage))
+		fmt.Fprintf(w, "%s.\n", cmd.summary)
+		for _, b := range splitDetails(cmd.details) {
+			if b.pre {
+				fmt.Fprintf(w, "\n```\n%s\n```\n", strings.Join(b.lines, "\n"))
+			} else {
+				fmt.Fprintf(w, "\n%s\n", strings.Join(b.lines, "\n"))
+			}
+		}
+
+		fmt.Fprintln(w, "\n| Option | Description |\n| --- | --- |")
+		for _, r := range commandRows(cmd) {
+			fmt.Fprintf(w, "| `%s` | %s |\n", strings.TrimSpace(r[0]), markdownCell(r[1]))
+		}
+
+		if len(cmd.examples) > 0 {
+			fmt.Fprintln(w, "\nExamples:\n\n```sh")
+			for i, e := range cmd.examples {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "# %s\n%s\n", e.desc, e.cmd)
+			}
+			fmt.Fprintln(w, "```")
+		}
+
+		if env := commandEnv(cmd); len(env) > 0 {
+			names := make([]string, len(env))
+			for i, e := range env {
+				names[i] = "`" + e.name + "`"
+			}
+			fmt.Fprintf(w, "\nEnvironment: %s\n", strings.Join(names, ", "))
+		}
+	}
+
+	fmt.Fprintln(w, "\n## Environment\n\n| Variable | Description |\n| --- | --- |")
+	for _, e := range environment {
+		fmt.Fprintf(w, "| `%s` | %s |\n", e.name, markdownCell(e.desc))
+	}
+	fmt.Fprintln(w, "\n## Files\n\n| Path | Description |\n| --- | --- |")
+	for _, f := range files {
+		fmt.Fprintf(w, "| `%s` | %s |\n", f[0], markdownCell(f[1]))
+	}
+	fmt.Fprintf(w, "\n## Exit status\n\n%s\n", exitStatus)
+}
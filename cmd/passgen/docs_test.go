@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitDetails(t *testing.T) {
+	got := splitDetails("Versions:\n  4  Random\n  7  Time-ordered\n\nFirst line\nsecond line\n")
+	want := []textBlock{
+		{lines: []string{"Versions:"}},
+		{pre: true, lines: []string{"4  Random", "7  Time-ordered"}},
+		{lines: []string{"First line", "second line"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitDetails() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRoff(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		code bool
+		want string
+	}{
+		{"Time-ordered", false, "Time-ordered"},
+		{"--salt-fd FD", true, `\-\-salt\-fd FD`},
+		{`a\b`, false, `a\eb`},
+		{".hidden", false, `\&.hidden`},
+		{"'quoted'", false, `\&'quoted'`},
+	} {
+		if got := roff(tt.in, tt.code); got != tt.want {
+			t.Errorf("roff(%q, %v) = %q, want %q", tt.in, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestManuals(t *testing.T) {
+	var man, md strings.Builder
+	writeMan(&man)
+	writeMarkdown(&md)
+
+	for _, cmd := range commands {
+		if !strings.Contains(man.String(), ".SS "+roff(progName+" "+cmd.name, true)) {
+			t.Errorf("man page is missing %q", cmd.name)
+		}
+		if !strings.Contains(md.String(), "\n## "+progName+" "+cmd.name+"\n") {
+			t.Errorf("Markdown reference is missing %q", cmd.name)
+		}
+		for _, r := range commandRows(cmd) {
+			if !strings.Contains(md.String(), "| `"+strings.TrimSpace(r[0])+"` |") {
+				t.Errorf("Markdown reference is missing %s %s", cmd.name, r[0])
+			}
+		}
+		for _, e := range cmd.env {
+			if !slices.Contains(environment, e) {
+				t.Errorf("%s reads %s, which is not in the ENVIRONMENT section", cmd.name, e.name)
+			}
+		}
+	}
+}
+
+func TestPrintCommandHelp(t *testing.T) {
+	var sb strings.Builder
+	printCommandHelp(&sb, genCommand)
+	out := sb.String()
+	for _, want := range []string{"\nOptions:\n", "\nExamples:\n  # ", "\n  passgen gen github.com\n", "\nEnvironment:\n  PASSGEN_SALT "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printCommandHelp() missing %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+)
+
+var envCommand = &command{
+	name:    "env",
+	usage:   "[OPTIONS] [COMMAND [ARGS...]]",
+	summary: "Show the effective options of a command and where each came from",
+	details: `Resolves the options of COMMAND (default: gen) as it would run with ARGS,
+without running it, and prints each value with its source:
+
+  flag          given on the command line
+  site NAME     stored for the site named by 'passgen gen SITE'
+  env           read from PASSGEN_<OPTION>, e.g. PASSGEN_LENGTH for --length
+  profile NAME  the profile selected with --profile
+  config        the defaults of the configuration file
+  default       built in
+
+Sources are listed from the highest precedence. Options without an ENV
+variable are never read from the environment. The salt and the input are
+never printed.`,
+	examples: []example{
+		{"Check what 'passgen gen' will use in CI", "passgen env"},
+		{"Debug a profile and a site together", "passgen env gen -P work github.com"},
+	},
+	env: []envVar{envOptions, envSalt, envConfigHome, envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var output outputOptions
+		output.register(fs)
+		fs.stopAtArg = true
+
+		return func() error {
+			if err := output.validate(); err != nil {
+				return err
+			}
+			if output.format == "env" {
+				return errors.New("--format env is not supported for lists (use json)")
+			}
+
+			cmd, args := genCommand, fs.Args()
+			if len(args) > 0 {
+				cmd, args = lookupCommand(args)
+				if cmd == nil {
+					return fmt.Errorf("unknown command %q", fs.Args()[0])
+				}
+			}
+
+			target, err := resolveOptions(cmd, args)
+			if errors.Is(err, errHelp) {
+				printCommandHelp(os.Stdout, cmd)
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if output.structured() {
+				for _, o := range target.options {
+					if o.hidden {
+						continue
+					}
+					value, source := effectiveValue(o)
+					r := newRecord("env").with("command", cmd.name).with("option", o.long).
+						with("value", value).with("source", source)
+					if slices.Contains(cmd.envDefaults, o.long) {
+						r = r.with("env", envName(o))
+					}
+					if err := output.print(r); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "OPTION\tVALUE\tSOURCE\tENV")
+			for _, o := range target.options {
+				if o.hidden {
+					continue
+				}
+				value, source := effectiveValue(o)
+				env := "-"
+				if slices.Contains(cmd.envDefaults, o.long) {
+					env = envName(o)
+				}
+				fmt.Fprintf(w, "--%s\t%s\t%s\t%s\n", o.long, value, source, env)
+			}
+			return w.Flush()
+		}
+	},
+}
+
+// resolveOptions parses args for cmd and applies the same layers as running
+// it would: flags, the environment, a stored site for 'gen SITE', the
+// selected profile and the configuration file.
+func resolveOptions(cmd *command, args []string) (*flagSet, error) {
+	fs := newFlagSet(cmd.name)
+	cmd.setup(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := fs.applyEnv(os.LookupEnv, cmd.envDefaults); err != nil {
+		return nil, err
+	}
+
+	if cmd == genCommand && len(fs.Args()) == 1 {
+		st, err := storeOptions{path: fs.lookupLong("store").get()}.open()
+		if err != nil {
+			return nil, err
+		}
+		s, err := st.Get(fs.Args()[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := fs.applySiteLayer(siteLayer(s), "site "+s.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if o := fs.lookupLong("config"); o != nil {
+		config := configOptions{path: o.get(), profile: fs.lookupLong("profile").get()}
+		if err := config.apply(fs); err != nil {
+			return nil, err
+		}
+	}
+	return fs, nil
+}
+
+// Groups of options for the envDefaults of commands: the generation
+// parameters, how a result is shown, and the files read. Inputs, secrets,
+// file descriptors, output paths and switches such as --force or --kill are
+// never read from the environment, so a variable set for one command cannot
+// leak a secret into another or make it destructive.
+var (
+	envGenerator = []string{"length", "level", "counter", "encoding", "algo-version"}
+	envDisplay   = []string{"group", "group-sep", "group-inclusive", "group-lines", "clip-timeout", "clip-backend"}
+	envFiles     = []string{"config", "profile", "store", "vault"}
+)
+
+// secretValues lists the options whose values env never prints.
+var secretValues = []string{"salt", "input"}
+
+// effectiveValue returns the value and source of o for display, hiding
+// secrets. PASSGEN_SALT is reported here because resolveSalt reads it only
+// when the command runs.
+func effectiveValue(o *option) (value, source string) {
+	value, source = o.get(), o.source
+	if o.long == "salt" && source == "" && os.Getenv("PASSGEN_SALT") != "" {
+		source = "env"
+	}
+	if slices.Contains(secretValues, o.long) && source != "" {
+		value = "(hidden)"
+	}
+	if source == "" {
+		source = "default"
+	}
+	return value, source
+}
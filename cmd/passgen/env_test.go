@@ -0,0 +1,155 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+func TestResolveOptions(t *testing.T) {
+	path := writeConfig(t, `
+length = 20
+level = "strong"
+fingerprint = "emoji"
+
+[profile.work]
+level = "low"
+`, 0o600)
+	t.Setenv("PASSGEN_CONFIG", path)
+	t.Setenv("PASSGEN_LENGTH", "24")
+	t.Setenv("PASSGEN_COUNTER", "")
+	t.Setenv("PASSGEN_SALT", "secret")
+
+	fs, err := resolveOptions(genCommand, []string{"-P", "work", "-i", "github.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		long, value, source string
+	}{
+		{"input", "(hidden)", "flag"},
+		{"config", path, "env"},
+		{"length", "24", "env"},
+		{"level", "low", "profile work"},
+		{"fingerprint", "emoji", "config"},
+		{"encoding", "", "default"},
+		{"salt", "(hidden)", "env"},
+	} {
+		value, source := effectiveValue(fs.lookupLong(tt.long))
+		if value != tt.value || source != tt.source {
+			t.Errorf("--%s = %q from %q, want %q from %q", tt.long, value, source, tt.value, tt.source)
+		}
+	}
+}
+
+// A variable meant for one command must not make another destructive, pick
+// an output path or supply an input or a secret.
+func TestApplyEnv_Excluded(t *testing.T) {
+	for _, tt := range []struct {
+		cmd         *command
+		long, value string
+	}{
+		{genCommand, "input", "github.com"},
+		{genCommand, "salt", "secret"},
+		{genCommand, "salt-fd", "0"},
+		{genCommand, "random-salt", "true"},
+		{genCommand, "clip", "true"},
+		{genCommand, "qr-png", "/tmp/passgen.png"},
+		{verifyCommand, "input", "github.com"},
+		{uuidCommand, "input", "github.com"},
+		{tokenCommand, "length", "8"},
+		{batchCommand, "output", "/tmp/passgen-batch"},
+		{batchCommand, "format", "dir"},
+		{exportCommand, "force", "true"},
+		{exportCommand, "output", "/tmp/passgen-export"},
+		{exportCommand, "format", "keepass-xml"},
+		{vaultAddCommand, "force", "true"},
+		{agentCommand, "kill", "true"},
+		{agentCommand, "socket", "/tmp/agent.sock"},
+		{serveCommand, "socket", "/tmp/serve.sock"},
+		{serveCommand, "token-file", "/tmp/token"},
+		{siteAddCommand, "counter", "2"},
+		{siteRmCommand, "store", "/tmp/sites.json"},
+		{envCommand, "format", "env"},
+		{manCommand, "format", "markdown"},
+	} {
+		fs := newFlagSet(tt.cmd.name)
+		tt.cmd.setup(fs)
+		name := envName(fs.lookupLong(tt.long))
+		lookup := func(n string) (string, bool) { return tt.value, n == name }
+		if err := fs.applyEnv(lookup, tt.cmd.envDefaults); err != nil {
+			t.Errorf("%s: applyEnv() error = %v", tt.cmd.name, err)
+		}
+		if src := fs.Source(tt.long); src != "" {
+			t.Errorf("%s read --%s from %s", tt.cmd.name, tt.long, name)
+		}
+	}
+}
+
+func TestApplyEnv_Allowed(t *testing.T) {
+	fs := newFlagSet(genCommand.name)
+	genCommand.setup(fs)
+	env := map[string]string{"PASSGEN_LENGTH": "24", "PASSGEN_FORMAT": "json", "PASSGEN_STORE": "/tmp/sites.json"}
+	lookup := func(n string) (string, bool) {
+		v, ok := env[n]
+		return v, ok
+	}
+	if err := fs.applyEnv(lookup, genCommand.envDefaults); err != nil {
+		t.Fatal(err)
+	}
+	for _, long := range []string{"length", "format", "store"} {
+		if src := fs.Source(long); src != "env" {
+			t.Errorf("gen --%s source = %q, want env", long, src)
+		}
+	}
+}
+
+// Stored site parameters rank above the environment: a default meant for new
+// inputs must not change the password of a stored site.
+func TestSiteOverEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	store := filepath.Join(t.TempDir(), "sites.json")
+	st, err := site.Open(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Put(site.Site{Name: "github.com", Length: 16, Level: "strong", Counter: 3, AlgorithmVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Save(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PASSGEN_STORE", store)
+	t.Setenv("PASSGEN_LENGTH", "24")
+	t.Setenv("PASSGEN_ENCODING", "hex")
+	t.Setenv("PASSGEN_COUNTER", "1")
+
+	fs, err := resolveOptions(genCommand, []string{"github.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		long, value string
+	}{
+		{"length", "16"},
+		{"level", "strong"},
+		{"encoding", ""},
+		{"counter", "3"},
+	} {
+		value, source := effectiveValue(fs.lookupLong(tt.long))
+		if value != tt.value || source != "site github.com" {
+			t.Errorf("--%s = %q from %q, want %q from the site", tt.long, value, source, tt.value)
+		}
+	}
+
+	out := strings.TrimSuffix(runStdout(t, "gen", "-s", "salt", "--fingerprint", "none", "github.com"), "\n")
+	if len(out) != 16 {
+		t.Errorf("gen github.com = %q, want the stored length 16", out)
+	}
+	if got := strings.TrimSuffix(runStdout(t, "gen", "-s", "salt", "--fingerprint", "none", "-l", "20", "github.com"), "\n"); len(got) != 20 {
+		t.Errorf("gen -l 20 github.com = %q, want a flag to win over the site", got)
+	}
+}
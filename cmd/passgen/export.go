@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/export"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+var exportCommand = &command{
+	name:    "export",
+	usage:   "--format FMT -o FILE [OPTIONS] [SITE...]",
+	summary: "Export stored sites with their passwords for another password manager",
+	details: `Regenerates the password of every stored site, or of each SITE given, and
+writes them with the username, URL and notes in an import format:
+
+  keepass-xml     KeePass 2.x XML (File > Import > KeePass XML (2.x))
+  bitwarden-json  Bitwarden unencrypted JSON
+  1password-csv   1Password CSV (Title, Website, Username, Password, Notes)
+
+The file is created with mode 0600 and holds every password in plain text.
+Import it and delete it right away; passgen stays the source of truth, so
+export again after rotating a password.`,
+	examples: []example{
+		{"Export everything for Bitwarden", "passgen export --format bitwarden-json -o passgen.json"},
+		{"Export two sites for KeePass with the salt from the vault", "passgen export --format keepass-xml -o sites.xml --salt-ref work github.com gitlab.com"},
+	},
+	env:         []envVar{envSalt, envAgentSock, envDataHome},
+	envDefaults: []string{"fingerprint", "store", "vault"},
+	setup: func(fs *flagSet) func() error {
+		var format, output, fingerprint string
+		var force bool
+		var store storeOptions
+		secrets := secretOptions{inputFD: -1, prompt: true}
+
+		fs.StringVar(&format, "format", "", "FMT", "", "Import format: keepass-xml, bitwarden-json or 1password-csv (required)")
+		fs.StringVar(&output, "output", "o", "FILE", "", "File to write (required)")
+		fs.BoolVar(&force, "force", "f", "Overwrite an existing file")
+		fs.StringVar(&fingerprint, "fingerprint", "", "STYLE", "words", "Salt fingerprint on stderr: words, emoji, identicon or none")
+		fs.BoolVar(&secrets.confirmSalt, "confirm-salt", "", "Prompt for the salt twice and require both to match")
+		fs.IntVar(&secrets.saltFD, "salt-fd", "", "FD", -1, "Read the salt from the first line of file descriptor FD")
+		fs.StringVar(&secrets.saltRef, "salt-ref", "", "NAME", "", "Read the salt from the vault entry NAME")
+		secrets.vault.register(fs)
+		store.register(fs)
+
+		return func() error {
+			if !validExportFormat(format) {
+				return fmt.Errorf("--format must be one of %s", exportFormatList())
+			}
+			if output == "" {
+				return errors.New("-o/--output is required")
+			}
+			// Fail before asking for the salt; writeExport checks again.
+			if err := checkExportPath(output, force); err != nil {
+				return err
+			}
+
+			st, err := store.open()
+			if err != nil {
+				return err
+			}
+			sites := st.List()
+			if len(fs.Args()) > 0 {
+				sites = nil
+				for _, name := range fs.Args() {
+					s, err := st.Get(name)
+					if err != nil {
+						return err
+					}
+					sites = append(sites, s)
+				}
+			}
+			if len(sites) == 0 {
+				return fmt.Errorf("no sites to export (add them with '%s site add')", progName)
+			}
+
+			var salt string
+			if err := secrets.resolveSalt(&salt); err != nil {
+				return err
+			}
+			if salt == "" {
+				return errors.New("salt is required (--salt-fd, --salt-ref, PASSGEN_SALT or the prompt)")
+			}
+			if err := printFingerprint(fingerprint, salt, ""); err != nil {
+				return err
+			}
+
+			entries := make([]export.Entry, len(sites))
+			for i, s := range sites {
+				e, err := exportEntry(s, salt)
+				if err != nil {
+					return fmt.Errorf("site %s: %v", s.Name, err)
+				}
+				entries[i] = e
+			}
+
+			if err := writeExport(output, force, export.Format(format), entries); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "Exported %d passwords to %s.\n\n", len(entries), output)
+			fmt.Fprintf(os.Stderr, "WARNING: %s contains every exported password in PLAIN TEXT.\n", output)
+			fmt.Fprintln(os.Stderr, "Import it into your password manager now, then delete it securely,")
+			fmt.Fprintf(os.Stderr, "for example with 'shred -u %s'. Do not sync, back up or share it.\n", output)
+			return nil
+		}
+	},
+}
+
+func validExportFormat(format string) bool {
+	for _, f := range export.Formats {
+		if format == string(f) {
+			return true
+		}
+	}
+	return false
+}
+
+func exportFormatList() string {
+	names := make([]string, len(export.Formats))
+	for i, f := range export.Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// exportEntry regenerates the password of s. The notes keep the generation
+// parameters so the entry can be traced back to passgen.
+func exportEntry(s site.Site, salt string) (export.Entry, error) {
+	password, err := passgen.Generate(s.Config(salt))
+	if err != nil {
+		return export.Entry{}, err
+	}
+
+	params := fmt.Sprintf("passgen: %s, length %d, counter %d, algorithm version %d",
+		siteCharset(s), s.Length, s.Counter, s.AlgorithmVersion)
+	notes := params
+	if s.Notes != "" {
+		notes = s.Notes + "\n\n" + params
+	}
+
+	return export.Entry{
+		Title:    s.Name,
+		Username: s.Username,
+		Password: password,
+		URL:      export.URL(s.Name),
+		Notes:    notes,
+	}, nil
+}
+
+// checkExportPath fails if path exists, unless force is set and it is a
+// regular file. Symlinks and devices are never overwritten.
+func checkExportPath(path string, force bool) error {
+	fi, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	case !force:
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+	case !fi.Mode().IsRegular():
+		return fmt.Errorf("%s is not a regular file; refusing to overwrite it", path)
+	}
+	return nil
+}
+
+// writeExport writes entries to a new file with mode 0600. With force an
+// existing regular file is replaced by renaming a temporary file over it, so
+// a symlink put in its place is replaced rather than followed.
+func writeExport(path string, force bool, format export.Format, entries []export.Entry) error {
+	if err := checkExportPath(path, force); err != nil {
+		return err
+	}
+	if !force {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists (use --force to overwrite it)", path)
+		} else if err != nil {
+			return err
+		}
+		if err := export.Write(f, format, entries); err != nil {
+			f.Close()
+			os.Remove(path)
+			return err
+		}
+		return f.Close()
+	}
+
+	// CreateTemp uses mode 0600.
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, format, entries); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/export"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+func TestExportEntry(t *testing.T) {
+	s := site.Site{Name: "github.com", Username: "alice", Length: 20, Level: passgen.LevelStrong, Counter: 3, AlgorithmVersion: 1, Notes: "work"}
+	want, _ := passgen.Generate(s.Config("salt"))
+
+	e, err := exportEntry(s, "salt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Password != want || e.Username != "alice" || e.URL != "https://github.com" {
+		t.Errorf("exportEntry() = %+v, want password %s", e, want)
+	}
+	if !strings.HasPrefix(e.Notes, "work\n\n") || !strings.Contains(e.Notes, "strong, length 20, counter 3") {
+		t.Errorf("notes = %q, want the site notes and the parameters", e.Notes)
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	entries := []export.Entry{{Title: "a", Password: "p"}}
+
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeExport(path, false, export.OnePasswordCSV, entries); err == nil {
+		t.Fatal("writeExport() should not overwrite without force")
+	}
+	if err := writeExport(path, true, export.OnePasswordCSV, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("mode = %o, want 600", perm)
+	}
+	if b, _ := os.ReadFile(path); !strings.Contains(string(b), "a,,,p,") {
+		t.Errorf("file = %q", b)
+	}
+}
+
+func TestWriteExport_Symlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "export.csv")
+	if err := os.Symlink(target, path); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	entries := []export.Entry{{Title: "a", Password: "p"}}
+	if err := writeExport(path, true, export.OnePasswordCSV, entries); err == nil {
+		t.Error("writeExport() should refuse to overwrite a symlink")
+	}
+	if err := writeExport(dir, true, export.OnePasswordCSV, entries); err == nil {
+		t.Error("writeExport() should refuse to overwrite a directory")
+	}
+	if b, _ := os.ReadFile(target); string(b) != "keep" {
+		t.Errorf("symlink target = %q, want it untouched", b)
+	}
+	if names, _ := os.ReadDir(dir); len(names) != 2 {
+		t.Errorf("directory has %d entries, want no temporary files left", len(names))
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+var genCommand = &command{
+	name:    "gen",
+	usage:   "[OPTIONS] [SITE]",
+	summary: "Generate a deterministic password from an input and salt",
+	details: "With a SITE argument the input is the site name and the length, level,\ncounter and encoding stored by 'passgen site add' are used unless overridden.",
+	examples: []example{
+		{"Generate a 20-character strong password", "passgen gen -i github.com -s \"$SALT\" -l 20 -L strong"},
+		{"Rotate a compromised password and copy it to the clipboard", "passgen gen -i github.com -c 2 --clip"},
+		{"Use the parameters stored for a site", "passgen gen github.com"},
+		{"Show five candidates and record the counter of the one a site accepts", "passgen gen -i example.com -l 16 -n 5"},
+	},
+	env:         []envVar{envSalt, envAgentSock, envConfigHome, envDataHome, envNoColor},
+	envDefaults: slices.Concat(envGenerator, envDisplay, envFiles, []string{"fingerprint", "format"}),
+	setup: func(fs *flagSet) func() error {
+		var input, salt, level, encoding, fingerprint string
+		var length, counter, count, algoVersion int
+		var randomSalt, fingerprintInput bool
+		var group groupOptions
+		var secrets secretOptions
+		var clip clipOptions
+		var qrCode qrOptions
+		var output outputOptions
+		var config configOptions
+		var store storeOptions
+
+		fs.StringVar(&input, "input", "i", "TEXT", "", "Input string (required)")
+		fs.StringVar(&salt, "salt", "s", "TEXT", "", "Salt string (or PASSGEN_SALT)")
+		fs.BoolVar(&randomSalt, "random-salt", "", "Generate a random salt for the password")
+		fs.IntVar(&length, "length", "l", "NUM", 64, "Password length (1-4096)")
+		fs.StringVar(&level, "level", "L", "LEVEL", "medium", "Security level: low, medium, strong")
+		fs.IntVar(&counter, "counter", "c", "NUM", 1, "Counter for rotating passwords")
+		fs.IntVar(&count, "count", "n", "NUM", 1, "Print NUM candidates from counters -c to -c+NUM-1 (1-100)")
+		fs.StringVar(&encoding, "encoding", "", "ENC", "", "Encode -l bytes as hex, base32, crockford, base58, base64url or z85 (ignores -L)")
+		fs.StringVar(&fingerprint, "fingerprint", "", "STYLE", "words", "Salt fingerprint on stderr: words, emoji, identicon or none")
+		fs.BoolVar(&fingerprintInput, "fingerprint-input", "", "Include the input in the fingerprint")
+		fs.IntVar(&algoVersion, "algo-version", "", "NUM", passgen.AlgorithmVersion, "Generation algorithm version")
+		config.register(fs)
+		store.register(fs)
+		group.register(fs)
+		secrets.register(fs)
+		clip.register(fs)
+		qrCode.register(fs)
+		output.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 1 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[1])
+			}
+
+			var entry *site.Site
+			if len(fs.Args()) == 1 {
+				if input != "" || secrets.inputStdin || secrets.inputFD >= 0 || secrets.inputRef != "" {
+					return errors.New("a site cannot be combined with -i/--input, --input-stdin, --input-fd or --input-ref")
+				}
+				st, err := store.open()
+				if err != nil {
+					return err
+				}
+				s, err := st.Get(fs.Args()[0])
+				if errors.Is(err, site.ErrNotFound) {
+					return fmt.Errorf("%v (add it with '%s site add')", err, progName)
+				} else if err != nil {
+					return err
+				}
+				if err := fs.applySiteLayer(siteLayer(s), "site "+s.Name); err != nil {
+					return err
+				}
+				entry = &s
+				input = s.Name
+			}
+
+			if err := config.apply(fs); err != nil {
+				return err
+			}
+			if err := secrets.resolveInput(&input); err != nil {
+				return err
+			}
+			if input == "" {
+				return errors.New("input is required (-i, --input, --input-stdin or --prompt)")
+			}
+			if err := group.validate(); err != nil {
+				return err
+			}
+			if group.inclusive && encoding != "" {
+				return errors.New("--group-inclusive cannot be used with --encoding")
+			}
+			if err := output.validate(); err != nil {
+				return err
+			}
+			if clip.enabled && output.structured() {
+				return errors.New("--clip cannot be combined with --format " + output.format)
+			}
+			if err := qrCode.validate(); err != nil {
+				return err
+			}
+			if qrCode.enabled() && (clip.enabled || output.structured() || randomSalt) {
+				return errors.New("--qr and --qr-png cannot be combined with --clip, --format or --random-salt")
+			}
+			if err := validateCount(count, output, clip, qrCode, group); err != nil {
+				return err
+			}
+			if count > 1 && randomSalt {
+				return errors.New("--count cannot be combined with --random-salt")
+			}
+
+			if randomSalt {
+				var err error
+				salt, err = passgen.GenerateRandomString(passgen.DefaultSaltLength)
+				if err != nil {
+					return fmt.Errorf("generating random salt: %v", err)
+				}
+			} else if err := secrets.resolveSalt(&salt); err != nil {
+				return err
+			}
+
+			cfg := passgen.Config{
+				Input:    input,
+				Salt:     salt,
+				Length:   group.contentLength(length),
+				Level:    passgen.Level(level),
+				Encoding: passgen.Encoding(encoding),
+				Counter:  counter,
+				Version:  algoVersion,
+			}
+
+			// Candidate i uses counter -c+i, so the counter of the one a site
+			// accepts is the value to record. Counters 0 and 1 are the same
+			// password, so a run of candidates starts at 1.
+			first := counter
+			if count > 1 {
+				first = max(counter, 1)
+			}
+			candidates := make([]passgen.Config, count)
+			passwords := make([]string, count)
+			for i := range candidates {
+				candidates[i] = cfg
+				candidates[i].Counter = first + i
+				var err error
+				if passwords[i], err = passgen.Generate(candidates[i]); err != nil {
+					return err
+				}
+			}
+			password := passwords[0]
+
+			fpInput := ""
+			if fingerprintInput {
+				fpInput = input
+			}
+
+			if output.structured() {
+				fp, err := renderFingerprint(fingerprint, salt, fpInput, false)
+				if err != nil {
+					return err
+				}
+				for i, c := range candidates {
+					if err := output.print(genRecord(c, passwords[i], randomSalt, fp, entry)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			if err := printFingerprint(fingerprint, salt, fpInput); err != nil {
+				return err
+			}
+
+			if count > 1 {
+				width := len(strconv.Itoa(candidates[count-1].Counter))
+				for i, c := range candidates {
+					fmt.Printf("%*d  %s\n", width, c.Counter, group.render(passwords[i]))
+				}
+				return nil
+			}
+
+			if randomSalt {
+				fmt.Println("--------------------------------------------------")
+				fmt.Printf("Salt:     %s\n", salt)
+				if clip.enabled {
+					fmt.Println("Password: (copied to clipboard)")
+				} else if group.lines {
+					fmt.Printf("Password:\n%s\n", group.render(password))
+				} else {
+					fmt.Printf("Password: %s\n", group.render(password))
+				}
+				fmt.Println("--------------------------------------------------")
+				fmt.Println("IMPORTANT: Save the Salt! It is required to recover this password.")
+			} else if qrCode.enabled() {
+				return qrCode.deliver(password)
+			} else if !clip.enabled {
+				fmt.Println(group.render(password))
+			}
+
+			if clip.enabled {
+				return clip.deliver(password)
+			}
+			return nil
+		}
+	},
+}
+
+func genRecord(cfg passgen.Config, password string, randomSalt bool, fingerprint string, entry *site.Site) record {
+	r := newRecord("gen").with("password", password)
+	if randomSalt {
+		r = r.with("salt", cfg.Salt)
+	}
+	r = r.with("algorithm_version", cmp.Or(cfg.Version, passgen.AlgorithmVersion))
+	if cfg.Encoding != passgen.EncodingNone {
+		r = r.with("encoding", string(cfg.Encoding))
+	} else {
+		r = r.with("level", string(cfg.Level))
+	}
+	r = r.with("length", cfg.Length).with("counter", max(cfg.Counter, 1))
+
+	if bits, err := passgen.Entropy(cfg); err == nil {
+		r = r.with("entropy_bits", roundBits(bits))
+	}
+	if fingerprint != "" {
+		r = r.with("fingerprint", fingerprint)
+	}
+	if entry != nil {
+		r = r.with("site", entry.Name)
+		if entry.Username != "" {
+			r = r.with("username", entry.Username)
+		}
+	}
+	return r
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/format"
+)
+
+type groupOptions struct {
+	size      int
+	sep       string
+	inclusive bool
+	lines     bool
+}
+
+func (g *groupOptions) register(fs *flagSet) {
+	fs.IntVar(&g.size, "group", "", "NUM", 0, "Insert a separator every NUM characters of output")
+	fs.StringVar(&g.sep, "group-sep", "", "TEXT", "-", "Separator used by --group")
+	fs.BoolVar(&g.inclusive, "group-inclusive", "", "Count separators toward --length")
+	fs.BoolVar(&g.lines, "group-lines", "", "Print each group on its own numbered line")
+}
+
+func (g groupOptions) validate() error {
+	if g.size < 0 {
+		return errors.New("--group must not be negative")
+	}
+	if g.size == 0 && (g.inclusive || g.lines) {
+		return errors.New("--group-inclusive and --group-lines require --group")
+	}
+	return nil
+}
+
+// contentLength returns how many characters to generate so that the rendered
+// output is length characters long when separators count toward the length.
+func (g groupOptions) contentLength(length int) int {
+	if g.size == 0 || !g.inclusive || g.lines {
+		return length
+	}
+	return format.ContentLength(length, g.size, len([]rune(g.sep)))
+}
+
+// maxCandidates bounds -n/--count of gen and random.
+const maxCandidates = 100
+
+// validateCount checks -n/--count against the options that deliver a single
+// secret.
+func validateCount(n int, output outputOptions, clip clipOptions, qrCode qrOptions, group groupOptions) error {
+	if n < 1 || n > maxCandidates {
+		return fmt.Errorf("--count must be between 1 and %d", maxCandidates)
+	}
+	if n == 1 {
+		return nil
+	}
+	if clip.enabled || qrCode.enabled() {
+		return errors.New("--count cannot be combined with --clip, --qr or --qr-png")
+	}
+	if group.lines {
+		return errors.New("--count cannot be combined with --group-lines")
+	}
+	if output.format == "env" {
+		return errors.New("--format env is not supported with --count (use json)")
+	}
+	return nil
+}
+
+func (g groupOptions) render(s string) string {
+	switch {
+	case g.size == 0:
+		return s
+	case g.lines:
+		return strings.TrimSuffix(format.Lines(s, g.size), "\n")
+	default:
+		return format.Group(s, g.size, g.sep)
+	}
+}
+
+func renderFingerprint(style, salt, input string, color bool) (string, error) {
+	if style == "none" {
+		return "", nil
+	}
+	fp, err := passgen.NewFingerprint(salt, input)
+	if err != nil {
+		return "", err
+	}
+	return fp.Render(passgen.FingerprintStyle(style), color)
+}
+
+func printFingerprint(style, salt, input string) error {
+	out, err := renderFingerprint(style, salt, input, useColor(os.Stderr))
+	if err != nil || out == "" {
+		return err
+	}
+
+	if style == string(passgen.FingerprintIdenticon) {
+		fmt.Fprintf(os.Stderr, "Fingerprint:\n%s", out)
+	} else {
+		fmt.Fprintf(os.Stderr, "Fingerprint: %s\n", out)
+	}
+	return nil
+}
+
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
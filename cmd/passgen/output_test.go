@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestValidateCount(t *testing.T) {
+	text := outputOptions{format: "text"}
+	tests := []struct {
+		name    string
+		n       int
+		output  outputOptions
+		clip    clipOptions
+		qrCode  qrOptions
+		group   groupOptions
+		wantErr bool
+	}{
+		{"one", 1, text, clipOptions{enabled: true}, qrOptions{}, groupOptions{}, false},
+		{"many", 5, outputOptions{format: "json"}, clipOptions{}, qrOptions{}, groupOptions{size: 4}, false},
+		{"zero", 0, text, clipOptions{}, qrOptions{}, groupOptions{}, true},
+		{"too many", maxCandidates + 1, text, clipOptions{}, qrOptions{}, groupOptions{}, true},
+		{"clip", 2, text, clipOptions{enabled: true}, qrOptions{}, groupOptions{}, true},
+		{"qr", 2, text, clipOptions{}, qrOptions{terminal: true}, groupOptions{}, true},
+		{"group lines", 2, text, clipOptions{}, qrOptions{}, groupOptions{size: 4, lines: true}, true},
+		{"env", 2, outputOptions{format: "env"}, clipOptions{}, qrOptions{}, groupOptions{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCount(tt.n, tt.output, tt.clip, tt.qrCode, tt.group)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
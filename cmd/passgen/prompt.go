@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen/vault"
+)
+
+type secretOptions struct {
+	prompt      bool
+	confirmSalt bool
+	inputStdin  bool
+	inputFD     int
+	saltFD      int
+	inputRef    string
+	saltRef     string
+	vault       vaultOptions
+	unlocked    *vault.Vault
+	noAgent     bool
+}
+
+func (o *secretOptions) register(fs *flagSet) {
+	fs.BoolVar(&o.prompt, "prompt", "p", "Prompt for the input and salt on the terminal without echo")
+	fs.BoolVar(&o.confirmSalt, "confirm-salt", "", "Prompt for the salt twice and require both to match")
+	fs.BoolVar(&o.inputStdin, "input-stdin", "", "Read the input from the first line of stdin")
+	fs.IntVar(&o.inputFD, "input-fd", "", "FD", -1, "Read the input from the first line of file descriptor FD")
+	fs.IntVar(&o.saltFD, "salt-fd", "", "FD", -1, "Read the salt from the first line of file descriptor FD")
+	fs.StringVar(&o.inputRef, "input-ref", "", "NAME", "", "Read the input from the vault entry NAME")
+	fs.StringVar(&o.saltRef, "salt-ref", "", "NAME", "", "Read the salt from the vault entry NAME")
+	o.vault.register(fs)
+}
+
+// resolveInput fills *input from a file descriptor or a prompt when requested.
+func (o *secretOptions) resolveInput(input *string) error {
+	fd := o.inputFD
+	if o.inputStdin {
+		if fd >= 0 && fd != 0 {
+			return errors.New("--input-stdin and --input-fd are mutually exclusive")
+		}
+		fd = 0
+	}
+
+	if o.inputRef != "" {
+		if *input != "" || fd >= 0 {
+			return errors.New("--input-ref cannot be combined with -i/--input, --input-stdin or --input-fd")
+		}
+		s, err := o.vaultValue(o.inputRef, vault.KindInput)
+		if err != nil {
+			return err
+		}
+		*input = s
+		return nil
+	}
+
+	switch {
+	case fd >= 0:
+		if *input != "" {
+			return errors.New("-i/--input cannot be combined with --input-stdin or --input-fd")
+		}
+		s, err := readLineFD(fd)
+		if err != nil {
+			return fmt.Errorf("reading input from fd %d: %v", fd, err)
+		}
+		*input = s
+	case *input == "" && o.prompt:
+		s, err := promptSecret("Input: ")
+		if err != nil {
+			return err
+		}
+		*input = s
+	}
+	return nil
+}
+
+// resolveSalt applies the documented precedence: --salt, --salt-fd,
+// --salt-ref, PASSGEN_SALT, the agent named by PASSGEN_AGENT_SOCK, then the
+// terminal prompt.
+func (o *secretOptions) resolveSalt(salt *string) error {
+	if o.saltRef != "" {
+		if *salt != "" || o.saltFD >= 0 {
+			return errors.New("--salt-ref cannot be combined with -s/--salt or --salt-fd")
+		}
+		s, err := o.vaultValue(o.saltRef, vault.KindSalt)
+		if err != nil {
+			return err
+		}
+		*salt = s
+		return nil
+	}
+
+	if o.saltFD >= 0 {
+		if *salt != "" {
+			return errors.New("-s/--salt cannot be combined with --salt-fd")
+		}
+		s, err := readLineFD(o.saltFD)
+		if err != nil {
+			return fmt.Errorf("reading salt from fd %d: %v", o.saltFD, err)
+		}
+		*salt = s
+		return nil
+	}
+
+	if *salt == "" {
+		*salt = os.Getenv("PASSGEN_SALT")
+	}
+	if sock := os.Getenv(agentSockEnv); *salt == "" && sock != "" && !o.noAgent {
+		s, err := agentSalt(sock)
+		switch {
+		case err == nil:
+			*salt = s
+			return nil
+		case !errors.Is(err, errAgentLocked) || !(o.prompt || o.confirmSalt):
+			return err
+		}
+	}
+	if *salt != "" || !(o.prompt || o.confirmSalt) {
+		return nil
+	}
+
+	s, err := promptSecret("Salt: ")
+	if err != nil {
+		return err
+	}
+	if o.confirmSalt {
+		again, err := promptSecret("Confirm salt: ")
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(s), []byte(again)) != 1 {
+			return errors.New("salts do not match")
+		}
+	}
+	*salt = s
+	return nil
+}
+
+// vaultValue returns the value of the vault entry name, unlocking the vault
+// on first use.
+func (o *secretOptions) vaultValue(name string, kind vault.Kind) (string, error) {
+	if o.unlocked == nil {
+		v, err := o.vault.open()
+		if err != nil {
+			return "", err
+		}
+		o.unlocked = v
+	}
+
+	e, err := o.unlocked.Get(name)
+	if err != nil {
+		return "", err
+	}
+	if e.Kind != kind {
+		return "", fmt.Errorf("vault entry %q is of kind %s, not %s", name, e.Kind, kind)
+	}
+	return e.Value, nil
+}
+
+func promptSecret(label string) (string, error) {
+	if ttyPath == "" {
+		return "", errors.New("no-echo prompts are not supported on this platform")
+	}
+
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal available for prompt: %v", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, label)
+	line, err := readNoEcho(tty)
+	fmt.Fprintln(tty)
+	if err != nil {
+		return "", err
+	}
+	return string(line), nil
+}
+
+var fdReaders = map[int]*bufio.Reader{}
+
+// readLineFD returns the next line from fd. Readers are cached so that
+// several values can be read in order from the same descriptor.
+func readLineFD(fd int) (string, error) {
+	r, ok := fdReaders[fd]
+	if !ok {
+		var f *os.File
+		if fd == 0 {
+			f = os.Stdin
+		} else {
+			f = os.NewFile(uintptr(fd), "fd"+strconv.Itoa(fd))
+			if f == nil {
+				return "", errors.New("invalid file descriptor")
+			}
+		}
+		r = bufio.NewReader(f)
+		fdReaders[fd] = r
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return trimNewline(line), nil
+}
+
+func readLine(r io.Reader) ([]byte, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n == 1 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				break
+			}
+			return nil, err
+		}
+	}
+	return []byte(trimNewline(string(line))), nil
+}
+
+func trimNewline(s string) string {
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == '\r') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen/qr"
+)
+
+type qrOptions struct {
+	terminal bool
+	png      string
+	level    string
+	wifi     string
+	otpauth  string
+}
+
+func (o *qrOptions) register(fs *flagSet) {
+	fs.BoolVar(&o.terminal, "qr", "", "Show the secret as a QR code on the terminal instead of printing it")
+	fs.StringVar(&o.png, "qr-png", "", "FILE", "", "Write the QR code to FILE as a PNG")
+	fs.StringVar(&o.level, "qr-level", "", "LEVEL", "M", "QR error correction level: L, M, Q or H")
+	fs.StringVar(&o.wifi, "qr-wifi", "", "SSID", "", "Encode a Wi-Fi join string for SSID with the secret as its password")
+	fs.StringVar(&o.otpauth, "qr-otpauth", "", "LABEL", "", "Encode an otpauth:// URI with the secret as the TOTP key (needs base32)")
+}
+
+func (o qrOptions) enabled() bool {
+	return o.terminal || o.png != ""
+}
+
+func (o qrOptions) validate() error {
+	if _, err := qr.ParseLevel(o.level); err != nil {
+		return err
+	}
+	if o.wifi != "" && o.otpauth != "" {
+		return errors.New("--qr-wifi and --qr-otpauth are mutually exclusive")
+	}
+	if !o.enabled() && (o.wifi != "" || o.otpauth != "") {
+		return errors.New("--qr-wifi and --qr-otpauth need --qr or --qr-png")
+	}
+	return nil
+}
+
+// payload returns the text to encode: the secret itself, a Wi-Fi join
+// string or an otpauth:// URI.
+func (o qrOptions) payload(secret string) (string, error) {
+	switch {
+	case o.wifi != "":
+		return "WIFI:T:WPA;S:" + wifiEscape(o.wifi) + ";P:" + wifiEscape(secret) + ";;", nil
+	case o.otpauth != "":
+		key := strings.ToUpper(strings.TrimRight(secret, "="))
+		if key == "" || strings.Trim(key, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") != "" {
+			return "", errors.New("--qr-otpauth needs a base32 secret (use --encoding base32)")
+		}
+		v := url.Values{"secret": {key}}
+		if issuer, _, ok := strings.Cut(o.otpauth, ":"); ok {
+			v.Set("issuer", issuer)
+		}
+		return "otpauth://totp/" + url.PathEscape(o.otpauth) + "?" + v.Encode(), nil
+	}
+	return secret, nil
+}
+
+// wifiEscape escapes the characters that are special in the Wi-Fi join
+// string format.
+func wifiEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`\;,":`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// deliver renders secret as a QR code on stdout and/or into the PNG file.
+func (o qrOptions) deliver(secret string) error {
+	text, err := o.payload(secret)
+	if err != nil {
+		return err
+	}
+	level, err := qr.ParseLevel(o.level)
+	if err != nil {
+		return err
+	}
+	code, err := qr.Encode([]byte(text), level)
+	if err != nil {
+		return err
+	}
+
+	if o.png != "" {
+		if err := writeQRPNG(o.png, code); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "QR code written to %s (version %d-%s).\n", o.png, code.Version, code.Level)
+	}
+	if o.terminal {
+		color := isTerminal(os.Stdout)
+		for _, line := range strings.SplitAfter(code.Text(4), "\n") {
+			if line == "" {
+				continue
+			}
+			// Light blocks on an explicit black background read the same
+			// on light and dark terminal themes.
+			if color {
+				line = "\x1b[97;40m" + strings.TrimSuffix(line, "\n") + "\x1b[0m\n"
+			}
+			fmt.Print(line)
+		}
+	}
+	return nil
+}
+
+// writeQRPNG writes the code at 8 pixels per module to a new file with mode
+// 0600. Existing files are not overwritten.
+func writeQRPNG(path string, code *qr.Code) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, code.Image(8, 4)); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestQROptions_Payload(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		opts   qrOptions
+		secret string
+		want   string
+	}{
+		{"secret", qrOptions{}, "p@ss", "p@ss"},
+		{"wifi", qrOptions{wifi: `Home;Net`}, `a:b\c"d,e`, `WIFI:T:WPA;S:Home\;Net;P:a\:b\\c\"d\,e;;`},
+		{"otpauth", qrOptions{otpauth: "GitHub:alice@example.com"}, "JBSWY3DPEHPK3PXP", "otpauth://totp/GitHub:alice@example.com?issuer=GitHub&secret=JBSWY3DPEHPK3PXP"},
+		{"otpauth without issuer", qrOptions{otpauth: "my key"}, "jbswy3dp====", "otpauth://totp/my%20key?secret=JBSWY3DP"},
+	} {
+		got, err := tt.opts.payload(tt.secret)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: payload() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := (qrOptions{otpauth: "x"}).payload("not-base32!"); err == nil {
+		t.Error("payload() should reject a secret that is not base32")
+	}
+}
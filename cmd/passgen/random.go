@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+var randomCommand = &command{
+	name:    "random",
+	usage:   "[OPTIONS]",
+	summary: "Generate a random string",
+	examples: []example{
+		{"Generate a 32-character random string", "passgen random -l 32"},
+		{"Generate 32 random bytes as base64url", "passgen random -l 32 --encoding base64url"},
+		{"Print five strings to choose from", "passgen random -l 20 -n 5"},
+	},
+	envDefaults: slices.Concat([]string{"length", "encoding"}, envDisplay, []string{"format"}),
+	setup: func(fs *flagSet) func() error {
+		var length, count int
+		var encoding string
+		var group groupOptions
+		var clip clipOptions
+		var qrCode qrOptions
+		var output outputOptions
+
+		fs.IntVar(&length, "length", "l", "NUM", 64, "String length, or bytes with --encoding (1-4096)")
+		fs.StringVar(&encoding, "encoding", "", "ENC", "", "Encode random bytes as hex, base32, crockford, base58, base64url or z85")
+		fs.IntVar(&count, "count", "n", "NUM", 1, "Print NUM independent strings (1-100)")
+		group.register(fs)
+		clip.register(fs)
+		qrCode.register(fs)
+		output.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			if err := group.validate(); err != nil {
+				return err
+			}
+			if group.inclusive && encoding != "" {
+				return errors.New("--group-inclusive cannot be used with --encoding")
+			}
+			if err := output.validate(); err != nil {
+				return err
+			}
+			if clip.enabled && output.structured() {
+				return errors.New("--clip cannot be combined with --format " + output.format)
+			}
+			if err := qrCode.validate(); err != nil {
+				return err
+			}
+			if qrCode.enabled() && (clip.enabled || output.structured()) {
+				return errors.New("--qr and --qr-png cannot be combined with --clip or --format")
+			}
+			if err := validateCount(count, output, clip, qrCode, group); err != nil {
+				return err
+			}
+
+			strs := make([]string, count)
+			for i := range strs {
+				var err error
+				if encoding != "" {
+					strs[i], err = passgen.GenerateRandomEncoded(length, passgen.Encoding(encoding))
+				} else {
+					strs[i], err = passgen.GenerateRandomString(group.contentLength(length))
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if output.structured() {
+				for _, s := range strs {
+					r := newRecord("random").with("password", s)
+					if encoding != "" {
+						r = r.with("encoding", encoding).with("length", length).with("entropy_bits", roundBits(8*float64(length)))
+					} else {
+						r = r.with("length", len(s)).with("entropy_bits", roundBits(passgen.RandomStringEntropy(len(s))))
+					}
+					if err := output.print(r); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			if clip.enabled {
+				return clip.deliver(strs[0])
+			}
+			if qrCode.enabled() {
+				return qrCode.deliver(strs[0])
+			}
+			for _, s := range strs {
+				fmt.Println(group.render(s))
+			}
+			return nil
+		}
+	},
+}
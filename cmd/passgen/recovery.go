@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+var recoveryCodesCommand = &command{
+	name:    "recovery-codes",
+	usage:   "[OPTIONS]",
+	summary: "Generate a set of unique, unambiguous recovery codes",
+	examples: []example{
+		{"Print ten random recovery codes", "passgen recovery-codes --random"},
+		{"Derive the same codes again from an input and salt", "passgen recovery-codes -i github.com -n 16"},
+	},
+	env:         []envVar{envSalt, envAgentSock},
+	envDefaults: []string{"groups", "group-size", "separator", "vault", "format"},
+	setup: func(fs *flagSet) func() error {
+		var input, salt, separator string
+		var count, groups, groupSize int
+		var random bool
+		var secrets secretOptions
+		var output outputOptions
+
+		fs.StringVar(&input, "input", "i", "TEXT", "", "Input string (deterministic mode)")
+		fs.StringVar(&salt, "salt", "s", "TEXT", "", "Salt string (or PASSGEN_SALT)")
+		fs.BoolVar(&random, "random", "", "Draw codes from crypto/rand instead of the input")
+		fs.IntVar(&count, "count", "n", "NUM", 10, "Number of codes (1-100)")
+		fs.IntVar(&groups, "groups", "", "NUM", passgen.DefaultRecoveryFormat.Groups, "Groups per code")
+		fs.IntVar(&groupSize, "group-size", "", "NUM", passgen.DefaultRecoveryFormat.GroupSize, "Characters per group")
+		fs.StringVar(&separator, "separator", "", "TEXT", passgen.DefaultRecoveryFormat.Separator, "Separator between groups")
+		secrets.register(fs)
+		output.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			if err := output.validate(); err != nil {
+				return err
+			}
+			if !random {
+				if err := secrets.resolveInput(&input); err != nil {
+					return err
+				}
+			}
+			if random == (input != "") {
+				return errors.New("use exactly one of -i/--input or --random")
+			}
+			if !random {
+				if err := secrets.resolveSalt(&salt); err != nil {
+					return err
+				}
+			}
+
+			format := passgen.RecoveryFormat{
+				Groups:    groups,
+				GroupSize: groupSize,
+				Separator: separator,
+			}
+
+			codes, err := passgen.GenerateRecoveryCodes(passgen.Config{Input: input, Salt: salt}, count, format)
+			if err != nil {
+				return err
+			}
+
+			if output.structured() {
+				return output.print(newRecord("recovery-codes").
+					with("codes", codes).
+					with("deterministic", !random).
+					with("entropy_bits", roundBits(format.Entropy())))
+			}
+
+			for _, code := range codes {
+				fmt.Println(code)
+			}
+			return nil
+		}
+	},
+}
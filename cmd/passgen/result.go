@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// resultSchema is the version of the --format json and env output. Fields may
+// be added without bumping it; renaming or removing one requires a new version.
+const resultSchema = 1
+
+// envOutputPrefix starts the variables printed by --format env. It differs
+// from envPrefix so that sourcing the output never changes what passgen reads,
+// such as PASSGEN_SALT.
+const envOutputPrefix = "PASSGEN_OUT_"
+
+type field struct {
+	key   string
+	value any
+}
+
+// record is an ordered set of output fields, so JSON keys and env variables
+// always come out in the documented order.
+type record []field
+
+func newRecord(mode string) record {
+	return record{{"schema", resultSchema}, {"mode", mode}}
+}
+
+func (r record) with(key string, value any) record {
+	return append(r, field{key, value})
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(f.key)
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (r record) env() (string, error) {
+	var sb strings.Builder
+	for _, f := range r {
+		var v string
+		switch x := f.value.(type) {
+		case string:
+			v = x
+		case int:
+			v = strconv.Itoa(x)
+		case float64:
+			v = strconv.FormatFloat(x, 'f', 2, 64)
+		case bool:
+			v = strconv.FormatBool(x)
+		case []string:
+			v = strings.Join(x, " ")
+		default:
+			b, err := json.Marshal(x)
+			if err != nil {
+				return "", err
+			}
+			v = string(b)
+		}
+		key := envOutputPrefix + strings.ToUpper(strings.ReplaceAll(f.key, "-", "_"))
+		fmt.Fprintf(&sb, "%s=%s\n", key, shellQuote(v))
+	}
+	return sb.String(), nil
+}
+
+func roundBits(bits float64) float64 {
+	return math.Round(bits*100) / 100
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type outputOptions struct {
+	format string
+}
+
+func (o *outputOptions) register(fs *flagSet) {
+	fs.StringVar(&o.format, "format", "", "FMT", "text", "Output format: text, json or env")
+}
+
+func (o outputOptions) validate() error {
+	switch o.format {
+	case "text", "json", "env":
+		return nil
+	}
+	return fmt.Errorf("invalid output format %q (use text, json or env)", o.format)
+}
+
+func (o outputOptions) structured() bool {
+	return o.format != "text"
+}
+
+func (o outputOptions) print(r record) error {
+	switch o.format {
+	case "json":
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", b)
+		return err
+	case "env":
+		s, err := r.env()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprint(os.Stdout, s)
+		return err
+	}
+	return fmt.Errorf("invalid output format %q", o.format)
+}
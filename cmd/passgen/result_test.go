@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+// The variables carry envOutputPrefix so that sourcing them never overwrites
+// PASSGEN_SALT or another variable passgen reads.
+func TestRecord_Env(t *testing.T) {
+	r := newRecord("gen").with("password", "it's").with("salt", "abc").
+		with("length", 16).with("codes", []string{"a", "b"})
+	got, err := r.env()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `PASSGEN_OUT_SCHEMA='1'
+PASSGEN_OUT_MODE='gen'
+PASSGEN_OUT_PASSWORD='it'\''s'
+PASSGEN_OUT_SALT='abc'
+PASSGEN_OUT_LENGTH='16'
+PASSGEN_OUT_CODES='a b'
+`
+	if got != want {
+		t.Errorf("env() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen/rpc"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+var rpcCommand = &command{
+	name:    "rpc",
+	usage:   "[OPTIONS]",
+	summary: "Serve JSON-RPC 2.0 requests on stdin and stdout",
+	details: `Reads one JSON-RPC 2.0 request or batch per line from stdin and writes one
+response per line to stdout until stdin is closed. Methods:
+
+  generate     passgen.Config fields, or Site to use a stored site
+  random       Length, Encoding
+  entropy      passgen.Config fields
+  verify       passgen.Config fields plus Candidate
+  listSites    no params
+  fingerprint  Salt, Input, Style
+
+Requests that leave Salt empty use the salt from --salt-fd or --salt-ref, read
+once at startup, or else PASSGEN_SALT or the agent, asked on every request.`,
+	examples: []example{
+		{"Generate one password", "passgen rpc <<< '{\"jsonrpc\": \"2.0\", \"id\": 1, \"method\": \"generate\", \"params\": {\"Site\": \"github.com\"}}'"},
+	},
+	env:         []envVar{envSalt, envAgentSock, envDataHome},
+	envDefaults: []string{"store", "vault"},
+	setup: func(fs *flagSet) func() error {
+		var store storeOptions
+		secrets := secretOptions{inputFD: -1}
+
+		fs.IntVar(&secrets.saltFD, "salt-fd", "", "FD", -1, "Read the salt from the first line of file descriptor FD")
+		fs.StringVar(&secrets.saltRef, "salt-ref", "", "NAME", "", "Read the salt from the vault entry NAME")
+		secrets.vault.register(fs)
+		store.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+
+			srv := &rpc.Server{
+				Sites: func() (*site.Store, error) { return store.open() },
+			}
+			if secrets.saltFD >= 0 || secrets.saltRef != "" {
+				var salt string
+				if err := secrets.resolveSalt(&salt); err != nil {
+					return err
+				}
+				srv.Salt = func() (string, error) { return salt, nil }
+			} else {
+				srv.Salt = func() (string, error) {
+					var salt string
+					err := secrets.resolveSalt(&salt)
+					return salt, err
+				}
+			}
+			return srv.Serve(os.Stdin, os.Stdout)
+		}
+	},
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/server"
+)
+
+var serveCommand = &command{
+	name:    "serve",
+	usage:   "[OPTIONS]",
+	summary: "Serve the generators over a local HTTP API",
+	details: `Endpoints (POST, JSON, "Authorization: Bearer <token>"):
+
+  /v1/generate  passgen.Config fields: Input, Salt, Length, Level, Encoding, Counter, Version
+  /v1/random    Length, Encoding
+  /v1/verify    passgen.Config fields plus Candidate
+
+The token is read from --token-file, --token-fd or PASSGEN_SERVE_TOKEN. If none
+is given a random token is generated and printed on stderr. Only loopback
+addresses are accepted; use --socket for a Unix socket.`,
+	examples: []example{
+		{"Serve on a private Unix socket", "passgen serve --socket \"$XDG_RUNTIME_DIR/passgen.sock\" --token-file ~/.passgen-token"},
+	},
+	env: []envVar{envServeToken},
+	setup: func(fs *flagSet) func() error {
+		var listen, socket, tokenFile string
+		var tokenFD int
+
+		fs.StringVar(&listen, "listen", "", "ADDR", "127.0.0.1:8787", "Loopback address to listen on")
+		fs.StringVar(&socket, "socket", "", "PATH", "", "Listen on a Unix socket (mode 0600) instead")
+		fs.StringVar(&tokenFile, "token-file", "", "PATH", "", "Read the bearer token from a file")
+		fs.IntVar(&tokenFD, "token-fd", "", "FD", -1, "Read the bearer token from file descriptor FD")
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			if socket != "" && fs.Changed("listen") {
+				return errors.New("--listen and --socket are mutually exclusive")
+			}
+
+			token, generated, err := serveToken(tokenFile, tokenFD)
+			if err != nil {
+				return err
+			}
+			handler, err := server.New(token)
+			if err != nil {
+				return err
+			}
+
+			var ln net.Listener
+			if socket != "" {
+				ln, err = listenUnix(socket)
+			} else {
+				ln, err = listenLoopback(listen)
+			}
+			if err != nil {
+				return err
+			}
+
+			srv := &http.Server{
+				Handler:           handler,
+				ReadHeaderTimeout: 5 * time.Second,
+				ReadTimeout:       10 * time.Second,
+				WriteTimeout:      10 * time.Second,
+				IdleTimeout:       60 * time.Second,
+				MaxHeaderBytes:    8 << 10,
+			}
+
+			if generated {
+				fmt.Fprintf(os.Stderr, "Token: %s\n", token)
+			}
+			fmt.Fprintf(os.Stderr, "Listening on %s\n", ln.Addr())
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			errc := make(chan error, 1)
+			go func() { errc <- srv.Serve(ln) }()
+
+			select {
+			case err := <-errc:
+				return err
+			case <-ctx.Done():
+			}
+
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdown)
+		}
+	},
+}
+
+// serveToken returns the bearer token and whether it was generated.
+func serveToken(file string, fd int) (string, bool, error) {
+	var token string
+	switch {
+	case file != "" && fd >= 0:
+		return "", false, errors.New("--token-file and --token-fd are mutually exclusive")
+	case file != "":
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return "", false, err
+		}
+		token = strings.TrimSpace(string(b))
+	case fd >= 0:
+		s, err := readLineFD(fd)
+		if err != nil {
+			return "", false, fmt.Errorf("reading token from fd %d: %v", fd, err)
+		}
+		token = strings.TrimSpace(s)
+	default:
+		token = os.Getenv("PASSGEN_SERVE_TOKEN")
+	}
+
+	if token != "" {
+		return token, false, nil
+	}
+	if file != "" || fd >= 0 {
+		return "", false, errors.New("the token is empty")
+	}
+	token, err := passgen.GenerateToken("pgs", passgen.DefaultTokenLength)
+	return token, true, err
+}
+
+func listenLoopback(addr string) (net.Listener, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("refusing to listen on %s: only loopback addresses are allowed", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// listenUnix listens on path with mode 0600, replacing a stale socket left
+// behind by a previous run. Other users can never connect, even briefly.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if c, err := net.Dial("unix", path); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bind under a 077 umask so the socket is private from the start; the
+	// chmod below only settles the exact mode.
+	restore := restrictUmask()
+	ln, err := net.Listen("unix", path)
+	restore()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
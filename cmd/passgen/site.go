@@ -0,0 +1,304 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+type storeOptions struct {
+	path string
+}
+
+func (o *storeOptions) register(fs *flagSet) {
+	fs.StringVar(&o.path, "store", "", "PATH", "", "Site store (default: $XDG_DATA_HOME/passgen/sites.json)")
+}
+
+func (o storeOptions) open() (*site.Store, error) {
+	path := o.path
+	if path == "" {
+		path = site.DefaultPath()
+	}
+	return site.Open(path)
+}
+
+// siteLayer returns the stored parameters of s keyed by the long flag they
+// correspond to, for flagSet.applySiteLayer. The encoding is set even when
+// empty, so that one from the environment or the configuration cannot change
+// the password of a site stored without it.
+func siteLayer(s site.Site) map[string]string {
+	values := map[string]string{
+		"length":       strconv.Itoa(s.Length),
+		"counter":      strconv.Itoa(s.Counter),
+		"algo-version": strconv.Itoa(s.AlgorithmVersion),
+		"encoding":     string(s.Encoding),
+	}
+	if s.Level != "" {
+		values["level"] = string(s.Level)
+	}
+	return values
+}
+
+func siteRecord(s site.Site) record {
+	r := newRecord("site").with("site", s.Name)
+	if s.Username != "" {
+		r = r.with("username", s.Username)
+	}
+	if s.Encoding != passgen.EncodingNone {
+		r = r.with("encoding", string(s.Encoding))
+	} else {
+		r = r.with("level", string(s.Level))
+	}
+	r = r.with("length", s.Length).
+		with("counter", s.Counter).
+		with("algorithm_version", s.AlgorithmVersion)
+	if s.Notes != "" {
+		r = r.with("notes", s.Notes)
+	}
+	return r.with("created", s.Created.Format(time.RFC3339)).
+		with("updated", s.Updated.Format(time.RFC3339))
+}
+
+func siteCharset(s site.Site) string {
+	if s.Encoding != passgen.EncodingNone {
+		return string(s.Encoding)
+	}
+	return string(s.Level)
+}
+
+func singleSiteArg(fs *flagSet) (string, error) {
+	switch len(fs.Args()) {
+	case 0:
+		return "", errors.New("site name is required")
+	case 1:
+		return fs.Args()[0], nil
+	}
+	return "", fmt.Errorf("unexpected argument %q", fs.Args()[1])
+}
+
+var siteAddCommand = &command{
+	name:    "site add",
+	usage:   "[OPTIONS] SITE",
+	summary: "Record the generation parameters of a site",
+	details: "Only parameters are stored, never the password or salt. With --update the\nexisting entry is kept and only the options given on the command line change.",
+	examples: []example{
+		{"Record a site that only allows 16 characters", "passgen site add -u alice -l 16 -L low example.com"},
+		{"Bump the counter after a rotation", "passgen site add --update -c 3 github.com"},
+	},
+	env:         []envVar{envConfigHome, envDataHome},
+	envDefaults: []string{"config", "profile"},
+	setup: func(fs *flagSet) func() error {
+		var username, level, encoding, notes string
+		var length, counter, algoVersion int
+		var update bool
+		var store storeOptions
+		var config configOptions
+
+		fs.StringVar(&username, "username", "u", "NAME", "", "Username or login for the site")
+		fs.IntVar(&length, "length", "l", "NUM", 64, "Password length (1-4096)")
+		fs.StringVar(&level, "level", "L", "LEVEL", "medium", "Security level: low, medium, strong")
+		fs.IntVar(&counter, "counter", "c", "NUM", 1, "Counter for rotating passwords")
+		fs.StringVar(&encoding, "encoding", "", "ENC", "", "Encode -l bytes instead of using a level")
+		fs.IntVar(&algoVersion, "algo-version", "", "NUM", passgen.AlgorithmVersion, "Generation algorithm version")
+		fs.StringVar(&notes, "notes", "", "TEXT", "", "Free-form notes (never put secrets here)")
+		fs.BoolVar(&update, "update", "", "Change an existing site instead of adding a new one")
+		store.register(fs)
+		config.register(fs)
+
+		return func() error {
+			name, err := singleSiteArg(fs)
+			if err != nil {
+				return err
+			}
+
+			st, err := store.open()
+			if err != nil {
+				return err
+			}
+
+			existing, err := st.Get(name)
+			switch {
+			case err == nil && !update:
+				return fmt.Errorf("site %q already exists (use --update to change it)", name)
+			case err != nil && update:
+				return err
+			case update:
+				// Flags win over the stored parameters, which win over
+				// configuration defaults.
+				if err := fs.applySiteLayer(siteLayer(existing), "site "+name); err != nil {
+					return err
+				}
+				if fs.Source("username") == "" {
+					username = existing.Username
+				}
+				if fs.Source("notes") == "" {
+					notes = existing.Notes
+				}
+			}
+			if err := config.apply(fs); err != nil {
+				return err
+			}
+
+			s := site.Site{
+				Name:             name,
+				Username:         username,
+				Length:           length,
+				Level:            passgen.Level(level),
+				Encoding:         passgen.Encoding(encoding),
+				Counter:          counter,
+				AlgorithmVersion: algoVersion,
+				Notes:            notes,
+			}
+			if s.Encoding != passgen.EncodingNone {
+				s.Level = ""
+			}
+			if err := st.Put(s); err != nil {
+				return err
+			}
+			if err := st.Save(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "Saved %s: %s, length %d, counter %d\n", name, siteCharset(s), s.Length, s.Counter)
+			return nil
+		}
+	},
+}
+
+var siteListCommand = &command{
+	name:        "site list",
+	usage:       "[OPTIONS]",
+	summary:     "List stored sites",
+	env:         []envVar{envDataHome},
+	envDefaults: []string{"store"},
+	setup: func(fs *flagSet) func() error {
+		var store storeOptions
+		var output outputOptions
+		store.register(fs)
+		output.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			if err := output.validate(); err != nil {
+				return err
+			}
+			if output.format == "env" {
+				return errors.New("--format env is not supported for lists (use json)")
+			}
+
+			st, err := store.open()
+			if err != nil {
+				return err
+			}
+
+			if output.structured() {
+				for _, s := range st.List() {
+					if err := output.print(siteRecord(s)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SITE\tUSERNAME\tLENGTH\tLEVEL\tCOUNTER\tUPDATED")
+			for _, s := range st.List() {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%d\t%s\n",
+					s.Name, s.Username, s.Length, siteCharset(s), s.Counter, s.Updated.Format(time.DateOnly))
+			}
+			return w.Flush()
+		}
+	},
+}
+
+var siteShowCommand = &command{
+	name:        "site show",
+	usage:       "[OPTIONS] SITE",
+	summary:     "Show the stored parameters of a site",
+	env:         []envVar{envDataHome},
+	envDefaults: []string{"store", "format"},
+	setup: func(fs *flagSet) func() error {
+		var store storeOptions
+		var output outputOptions
+		store.register(fs)
+		output.register(fs)
+
+		return func() error {
+			name, err := singleSiteArg(fs)
+			if err != nil {
+				return err
+			}
+			if err := output.validate(); err != nil {
+				return err
+			}
+
+			st, err := store.open()
+			if err != nil {
+				return err
+			}
+			s, err := st.Get(name)
+			if err != nil {
+				return err
+			}
+
+			if output.structured() {
+				return output.print(siteRecord(s))
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Site:\t%s\n", s.Name)
+			if s.Username != "" {
+				fmt.Fprintf(w, "Username:\t%s\n", s.Username)
+			}
+			if s.Encoding != passgen.EncodingNone {
+				fmt.Fprintf(w, "Encoding:\t%s\n", s.Encoding)
+			} else {
+				fmt.Fprintf(w, "Level:\t%s\n", s.Level)
+			}
+			fmt.Fprintf(w, "Length:\t%d\n", s.Length)
+			fmt.Fprintf(w, "Counter:\t%d\n", s.Counter)
+			fmt.Fprintf(w, "Algorithm:\t%d\n", s.AlgorithmVersion)
+			if s.Notes != "" {
+				fmt.Fprintf(w, "Notes:\t%s\n", s.Notes)
+			}
+			fmt.Fprintf(w, "Created:\t%s\n", s.Created.Format(time.RFC3339))
+			fmt.Fprintf(w, "Updated:\t%s\n", s.Updated.Format(time.RFC3339))
+			return w.Flush()
+		}
+	},
+}
+
+var siteRmCommand = &command{
+	name:    "site rm",
+	usage:   "[OPTIONS] SITE",
+	summary: "Remove a site from the store",
+	env:     []envVar{envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var store storeOptions
+		store.register(fs)
+
+		return func() error {
+			name, err := singleSiteArg(fs)
+			if err != nil {
+				return err
+			}
+
+			st, err := store.open()
+			if err != nil {
+				return err
+			}
+			if err := st.Remove(name); err != nil {
+				return err
+			}
+			return st.Save()
+		}
+	},
+}
@@ -0,0 +1,12 @@
+//go:build linux || darwin
+
+package main
+
+import "syscall"
+
+// restrictUmask makes new files private to the user until restore is called,
+// so that a socket is never reachable by others between bind and chmod.
+func restrictUmask() (restore func()) {
+	old := syscall.Umask(0o077)
+	return func() { syscall.Umask(old) }
+}
@@ -0,0 +1,28 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+const ttyPath = ""
+
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func readNoEcho(f *os.File) ([]byte, error) {
+	return nil, errors.New("no-echo prompts are not supported on this platform")
+}
+
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+func termSize(f *os.File) (int, int, error) {
+	return 0, 0, errors.New("terminal size is not supported on this platform")
+}
+
+func notifyResize(c chan<- os.Signal) {}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/clipboard"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+var tuiCommand = &command{
+	name:    "tui",
+	usage:   "[OPTIONS]",
+	summary: "Search sites and preview passwords in a terminal interface",
+	details: `Type to fuzzy-search the site store; a query that matches no site is used as
+the input. The password is regenerated as the parameters change and stays
+masked until revealed.
+
+  Up/Down         select a site          Tab/Shift-Tab   change the level
+  Left/Right      length -1/+1           Ctrl-Left/Right length -10/+10
+  PgUp/PgDn       counter +1/-1          Ctrl-R          reveal or mask
+  Ctrl-Y          copy                   Enter           copy and quit
+  Ctrl-S          save the site          Esc, Ctrl-C     quit`,
+	examples: []example{
+		{"Browse the site store", "passgen tui"},
+	},
+	env:         []envVar{envSalt, envAgentSock, envConfigHome, envDataHome},
+	envDefaults: slices.Concat(envGenerator, envDisplay, envFiles),
+	setup: func(fs *flagSet) func() error {
+		var level, encoding string
+		var length, counter int
+		var clip clipOptions
+		var config configOptions
+		var store storeOptions
+		secrets := secretOptions{inputFD: -1, prompt: true}
+
+		fs.IntVar(&length, "length", "l", "NUM", 64, "Initial length for inputs that are not stored sites")
+		fs.StringVar(&level, "level", "L", "LEVEL", "medium", "Initial security level: low, medium, strong")
+		fs.IntVar(&counter, "counter", "c", "NUM", 1, "Initial counter")
+		fs.StringVar(&encoding, "encoding", "", "ENC", "", "Initial encoding instead of a level")
+		fs.IntVar(&secrets.saltFD, "salt-fd", "", "FD", -1, "Read the salt from the first line of file descriptor FD")
+		fs.StringVar(&secrets.saltRef, "salt-ref", "", "NAME", "", "Read the salt from the vault entry NAME")
+		secrets.vault.register(fs)
+		fs.DurationVar(&clip.timeout, "clip-timeout", "", "DURATION", 45*time.Second, "Restore or clear the clipboard after DURATION (0 keeps it)")
+		fs.StringVar(&clip.backend, "clip-backend", "", "NAME", "auto", "Clipboard backend: auto, wl-copy, xclip, xsel or osc52")
+		config.register(fs)
+		store.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			if ttyPath == "" {
+				return errors.New("the terminal interface is not supported on this platform")
+			}
+			if err := config.apply(fs); err != nil {
+				return err
+			}
+
+			st, err := store.open()
+			if err != nil {
+				return err
+			}
+			var salt string
+			if err := secrets.resolveSalt(&salt); err != nil {
+				return err
+			}
+
+			fingerprint, err := renderFingerprint("words", salt, "", false)
+			if err != nil {
+				return err
+			}
+
+			m := newTUIModel(st.List(), salt, fingerprint, tuiParams{
+				length:   length,
+				level:    passgen.Level(level),
+				encoding: passgen.Encoding(encoding),
+				counter:  counter,
+				version:  passgen.AlgorithmVersion,
+			})
+			password, err := runTUI(m, st, clip)
+			if err != nil || password == "" {
+				return err
+			}
+			return clip.deliver(password)
+		}
+	},
+}
+
+type tuiParams struct {
+	length   int
+	level    passgen.Level
+	encoding passgen.Encoding
+	counter  int
+	version  int
+}
+
+func siteParams(s site.Site) tuiParams {
+	return tuiParams{length: s.Length, level: s.Level, encoding: s.Encoding, counter: s.Counter, version: s.AlgorithmVersion}
+}
+
+type tuiAction int
+
+const (
+	tuiNone tuiAction = iota
+	tuiQuit
+	tuiCopy
+	tuiCopyQuit
+	tuiSave
+)
+
+// tuiMaxLength is the longest password the generator accepts.
+const tuiMaxLength = 4096
+
+var tuiLevels = []passgen.Level{passgen.LevelLow, passgen.LevelMedium, passgen.LevelStrong}
+
+// tuiModel is the state of the terminal interface, kept free of I/O so that
+// it can be tested.
+type tuiModel struct {
+	sites    []site.Site
+	salt     string
+	defaults tuiParams
+
+	query    string
+	matches  []int
+	selected int
+	current  string
+	params   tuiParams
+	reveal   bool
+	status   string
+
+	fingerprint string
+}
+
+func newTUIModel(sites []site.Site, salt, fingerprint string, defaults tuiParams) *tuiModel {
+	m := &tuiModel{
+		sites:       sites,
+		salt:        salt,
+		defaults:    defaults,
+		params:      defaults,
+		fingerprint: fingerprint,
+	}
+	m.filter()
+	return m
+}
+
+// filter recomputes the matches for the query, best first.
+func (m *tuiModel) filter() {
+	type scored struct{ i, score int }
+	var found []scored
+	for i, s := range m.sites {
+		if score, ok := fuzzyScore(m.query, s.Name); ok {
+			found = append(found, scored{i, score})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b scored) int { return b.score - a.score })
+
+	m.matches = m.matches[:0]
+	for _, f := range found {
+		m.matches = append(m.matches, f.i)
+	}
+	m.selected = 0
+	m.load()
+}
+
+// load resets the parameters when the selected site changes.
+func (m *tuiModel) load() {
+	if s, ok := m.site(); ok {
+		if s.Name != m.current {
+			m.current = s.Name
+			m.params = siteParams(s)
+		}
+		return
+	}
+	if m.current != "" {
+		m.current = ""
+		m.params = m.defaults
+	}
+}
+
+func (m *tuiModel) site() (site.Site, bool) {
+	if len(m.matches) == 0 {
+		return site.Site{}, false
+	}
+	return m.sites[m.matches[m.selected]], true
+}
+
+// input is the selected site name, or the query when nothing matches.
+func (m *tuiModel) input() string {
+	if s, ok := m.site(); ok {
+		return s.Name
+	}
+	return strings.TrimSpace(m.query)
+}
+
+func (m *tuiModel) config() passgen.Config {
+	return passgen.Config{
+		Input:    m.input(),
+		Salt:     m.salt,
+		Length:   m.params.length,
+		Level:    m.params.level,
+		Encoding: m.params.encoding,
+		Counter:  m.params.counter,
+		Version:  m.params.version,
+	}
+}
+
+func (m *tuiModel) password() (string, error) {
+	cfg := m.config()
+	if cfg.Input == "" {
+		return "", errors.New("type a site name or an input")
+	}
+	return passgen.Generate(cfg)
+}
+
+func (m *tuiModel) handle(k key) tuiAction {
+	m.status = ""
+	p := &m.params
+
+	switch k.code {
+	case keyRune:
+		m.query += string(k.r)
+		m.filter()
+	case keyBackspace:
+		if m.query != "" {
+			_, n := utf8.DecodeLastRuneInString(m.query)
+			m.query = m.query[:len(m.query)-n]
+			m.filter()
+		}
+	case keyUp:
+		if m.selected > 0 {
+			m.selected--
+			m.load()
+		}
+	case keyDown:
+		if m.selected < len(m.matches)-1 {
+			m.selected++
+			m.load()
+		}
+	case keyLeft:
+		p.length = max(p.length-1, 1)
+	case keyRight:
+		p.length = min(p.length+1, tuiMaxLength)
+	case keyCtrlLeft:
+		p.length = max(p.length-10, 1)
+	case keyCtrlRight:
+		p.length = min(p.length+10, tuiMaxLength)
+	case keyPgUp:
+		p.counter++
+	case keyPgDn:
+		p.counter = max(p.counter-1, 1)
+	case keyTab, keyBackTab:
+		step := 1
+		if k.code == keyBackTab {
+			step = len(tuiLevels) - 1
+		}
+		i := slices.Index(tuiLevels, p.level)
+		if i < 0 || p.encoding != passgen.EncodingNone {
+			i = 0
+			step = 0
+		}
+		p.encoding = passgen.EncodingNone
+		p.level = tuiLevels[(i+step)%len(tuiLevels)]
+	case keyEnter:
+		return tuiCopyQuit
+	case keyEsc:
+		return tuiQuit
+	case keyCtrl:
+		switch k.r {
+		case 'c', 'd', 'q':
+			return tuiQuit
+		case 'r':
+			m.reveal = !m.reveal
+		case 'y':
+			return tuiCopy
+		case 's':
+			return tuiSave
+		case 'u':
+			m.query = ""
+			m.filter()
+		case 'p':
+			return m.handle(key{code: keyUp})
+		case 'n':
+			return m.handle(key{code: keyDown})
+		}
+	}
+	return tuiNone
+}
+
+// view renders the screen as lines no wider than width.
+func (m *tuiModel) view(width, height int) []string {
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, truncate(fmt.Sprintf(format, args...), width))
+	}
+
+	add("passgen  salt %s", m.fingerprint)
+	add("Search: %s", m.query)
+	add("")
+
+	rows := max(height-13, 1)
+	first := max(m.selected-rows+1, 0)
+	for i := first; i < first+rows; i++ {
+		if i >= len(m.matches) {
+			if i == 0 {
+				add("  (no matching site; the query is used as the input)")
+			} else {
+				add("")
+			}
+			continue
+		}
+		s := m.sites[m.matches[i]]
+		marker := "  "
+		if i == m.selected {
+			marker = "> "
+		}
+		if s.Username != "" {
+			add("%s%-30s %s", marker, s.Name, s.Username)
+		} else {
+			add("%s%s", marker, s.Name)
+		}
+	}
+
+	add("%s", strings.Repeat("-", min(width, 60)))
+	p := m.params
+	source := "new"
+	if m.current != "" {
+		source = "stored site"
+	}
+	add("Input     %s (%s)", m.input(), source)
+	add("Length    %-12d Left/Right", p.length)
+	if p.encoding != passgen.EncodingNone {
+		add("Encoding  %-12s Tab switches to a level", p.encoding)
+	} else {
+		add("Level     %-12s Tab/Shift-Tab", p.level)
+	}
+	add("Counter   %-12d PgUp/PgDn", p.counter)
+
+	password, err := m.password()
+	switch {
+	case err != nil:
+		add("Password  (%v)", err)
+	case m.reveal:
+		add("Password  %s", password)
+	default:
+		add("Password  %s  Ctrl-R reveals", strings.Repeat("*", min(utf8.RuneCountInString(password), 40)))
+	}
+	if bits, err := passgen.Entropy(m.config()); err == nil {
+		add("Entropy   %s bits", strconv.FormatFloat(bits, 'f', 2, 64))
+	} else {
+		add("Entropy   -")
+	}
+	add("%s", m.status)
+	add("Ctrl-Y copy  Enter copy and quit  Ctrl-S save site  Esc quit")
+	return lines
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+// fuzzyScore reports whether the characters of pattern appear in s in order,
+// ignoring case, and scores the match: consecutive characters, word starts
+// and a shorter s score higher.
+func fuzzyScore(pattern, s string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	r := []rune(strings.ToLower(s))
+
+	score, j, last := 0, 0, -1
+	for i := 0; i < len(r) && j < len(p); i++ {
+		if r[i] != p[j] {
+			continue
+		}
+		score++
+		switch {
+		case i == 0:
+			score += 8
+		case !unicode.IsLetter(r[i-1]) && !unicode.IsDigit(r[i-1]):
+			score += 6
+		}
+		if last >= 0 && i == last+1 {
+			score += 5
+		} else if last >= 0 {
+			score -= min(i-last-1, 3)
+		}
+		last = i
+		j++
+	}
+	if j < len(p) {
+		return 0, false
+	}
+	return score*16 - min(len(r), 15), true
+}
+
+type keyCode int
+
+const (
+	keyUnknown keyCode = iota
+	keyRune
+	keyCtrl
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyTab
+	keyBackTab
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyCtrlLeft
+	keyCtrlRight
+	keyPgUp
+	keyPgDn
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// parseKeys decodes the bytes of one terminal read. A lone ESC is the Escape
+// key; ESC followed by [ or O starts a control sequence.
+func parseKeys(b []byte) []key {
+	var keys []key
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c == 0x1b:
+			if i+1 == len(b) {
+				keys = append(keys, key{code: keyEsc})
+				i++
+				continue
+			}
+			if b[i+1] != '[' && b[i+1] != 'O' {
+				keys = append(keys, key{code: keyUnknown})
+				i += 2
+				continue
+			}
+			j := i + 2
+			for j < len(b) && (b[j] < 0x40 || b[j] > 0x7e) {
+				j++
+			}
+			if j == len(b) {
+				keys = append(keys, key{code: keyUnknown})
+				i = j
+				continue
+			}
+			keys = append(keys, key{code: csiKey(string(b[i+2:j]), b[j])})
+			i = j + 1
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{code: keyEnter})
+			i++
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{code: keyBackspace})
+			i++
+		case c == '\t':
+			keys = append(keys, key{code: keyTab})
+			i++
+		case c < 0x20:
+			keys = append(keys, key{code: keyCtrl, r: rune(c) + 'a' - 1})
+			i++
+		default:
+			r, n := utf8.DecodeRune(b[i:])
+			if r == utf8.RuneError || !unicode.IsPrint(r) {
+				keys = append(keys, key{code: keyUnknown})
+			} else {
+				keys = append(keys, key{code: keyRune, r: r})
+			}
+			i += n
+		}
+	}
+	return keys
+}
+
+func csiKey(params string, final byte) keyCode {
+	ctrl := strings.HasSuffix(params, ";5")
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		if ctrl {
+			return keyCtrlRight
+		}
+		return keyRight
+	case 'D':
+		if ctrl {
+			return keyCtrlLeft
+		}
+		return keyLeft
+	case 'Z':
+		return keyBackTab
+	case '~':
+		switch params {
+		case "5":
+			return keyPgUp
+		case "6":
+			return keyPgDn
+		}
+	}
+	return keyUnknown
+}
+
+// runTUI drives the interface on the controlling terminal. It returns the
+// password to copy when the user leaves with Enter.
+func runTUI(m *tuiModel, st *site.Store, clip clipOptions) (string, error) {
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("no terminal available: %v", err)
+	}
+	defer tty.Close()
+
+	restore, err := makeRaw(tty)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	fmt.Fprint(tty, "\x1b[?1049h")
+	defer fmt.Fprint(tty, "\x1b[?1049l")
+
+	var pending *tuiClip
+	defer func() { pending.restore() }()
+
+	input := make(chan []byte)
+	go func() {
+		for {
+			buf := make([]byte, 256)
+			n, err := tty.Read(buf)
+			if err != nil {
+				close(input)
+				return
+			}
+			input <- buf[:n]
+		}
+	}()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(resize)
+	defer signal.Stop(sigs)
+
+	var restored <-chan struct{}
+	for {
+		draw(tty, m)
+
+		select {
+		case b, ok := <-input:
+			if !ok {
+				return "", nil
+			}
+			for _, k := range parseKeys(b) {
+				switch m.handle(k) {
+				case tuiQuit:
+					return "", nil
+				case tuiCopyQuit:
+					password, err := m.password()
+					if err != nil {
+						m.status = err.Error()
+						continue
+					}
+					return password, nil
+				case tuiCopy:
+					password, err := m.password()
+					if err != nil {
+						m.status = err.Error()
+						continue
+					}
+					pending.restore()
+					pending, err = copyForTUI(clip, password)
+					if err != nil {
+						m.status = "copy failed: " + err.Error()
+						continue
+					}
+					restored = pending.done
+					m.status = pending.message
+				case tuiSave:
+					m.status = saveFromTUI(m, st)
+				}
+			}
+		case <-restored:
+			restored = nil
+			m.status = "Clipboard restored."
+		case <-resize:
+		case <-sigs:
+			return "", nil
+		}
+	}
+}
+
+func draw(tty *os.File, m *tuiModel) {
+	width, height, err := termSize(tty)
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	for i, line := range m.view(width, height) {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+	}
+	fmt.Fprintf(&b, "\x1b[2;%dH", min(utf8.RuneCountInString("Search: "+m.query)+1, width))
+	tty.WriteString(b.String())
+}
+
+// tuiClip is a password on the clipboard that is restored after the
+// timeout, or when the interface exits.
+type tuiClip struct {
+	cb          clipboard.Clipboard
+	secret      string
+	previous    string
+	hadPrevious bool
+	timer       *time.Timer
+	done        chan struct{}
+	message     string
+}
+
+func copyForTUI(clip clipOptions, secret string) (*tuiClip, error) {
+	cb, err := clipboard.New(clip.backend)
+	if err != nil {
+		return nil, err
+	}
+	previous, pasteErr := cb.Paste()
+	if err := cb.Copy(secret); err != nil {
+		return nil, err
+	}
+
+	c := &tuiClip{cb: cb, secret: secret, previous: previous, hadPrevious: pasteErr == nil, done: make(chan struct{})}
+	c.message = fmt.Sprintf("Copied to clipboard (%s).", cb.Name())
+	if clip.timeout > 0 {
+		c.message = fmt.Sprintf("Copied to clipboard (%s). Clearing in %s.", cb.Name(), clip.timeout)
+		c.timer = time.AfterFunc(clip.timeout, func() {
+			clipboard.Restore(c.cb, c.secret, c.previous, c.hadPrevious)
+			close(c.done)
+		})
+	}
+	return c, nil
+}
+
+// restore puts back the previous clipboard now unless the timer already did.
+func (c *tuiClip) restore() {
+	if c == nil || c.timer == nil {
+		return
+	}
+	if c.timer.Stop() {
+		clipboard.Restore(c.cb, c.secret, c.previous, c.hadPrevious)
+	}
+}
+
+func saveFromTUI(m *tuiModel, st *site.Store) string {
+	name := m.input()
+	if name == "" {
+		return "type a site name to save"
+	}
+	s, err := st.Get(name)
+	if errors.Is(err, site.ErrNotFound) {
+		s = site.Site{Name: name}
+	}
+	s.Length = m.params.length
+	s.Level = m.params.level
+	s.Encoding = m.params.encoding
+	s.Counter = m.params.counter
+	s.AlgorithmVersion = m.params.version
+	if err := st.Put(s); err != nil {
+		return "save failed: " + err.Error()
+	}
+	if err := st.Save(); err != nil {
+		return "save failed: " + err.Error()
+	}
+
+	m.sites = st.List()
+	m.current = name
+	m.filter()
+	return "Saved " + name + "."
+}
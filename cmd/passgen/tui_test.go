@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, s string
+		ok         bool
+	}{
+		{"", "anything", true},
+		{"gh", "github.com", true},
+		{"GH", "github.com", true},
+		{"gc", "github.com", true},
+		{"hg", "github.com", false},
+		{"githubx", "github.com", false},
+	} {
+		if _, ok := fuzzyScore(tt.pattern, tt.s); ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) ok = %v, want %v", tt.pattern, tt.s, ok, tt.ok)
+		}
+	}
+
+	better := [][2]string{
+		{"git", "github.com"},        // prefix and consecutive
+		{"git", "legit-site.org"},    // vs. a match in the middle
+		{"gl", "gitlab.com"},         // starts at the beginning
+		{"gl", "long.example.gl"},    // vs. a scattered match
+		{"ex", "example.org"},        // shorter
+		{"ex", "example.org.backup"}, // vs. longer
+	}
+	for i := 0; i < len(better); i += 2 {
+		a, _ := fuzzyScore(better[i][0], better[i][1])
+		b, _ := fuzzyScore(better[i+1][0], better[i+1][1])
+		if a <= b {
+			t.Errorf("fuzzyScore(%q, %q) = %d, want more than %q's %d", better[i][0], better[i][1], a, better[i+1][1], b)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("a\x1b[A\x1b[1;5C\x1b[5~\x1b[Z\x7f\r\t\x12é\x1b"))
+	want := []key{
+		{code: keyRune, r: 'a'},
+		{code: keyUp},
+		{code: keyCtrlRight},
+		{code: keyPgUp},
+		{code: keyBackTab},
+		{code: keyBackspace},
+		{code: keyEnter},
+		{code: keyTab},
+		{code: keyCtrl, r: 'r'},
+		{code: keyRune, r: 'é'},
+		{code: keyEsc},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeys() = %v, want %v", got, want)
+	}
+}
+
+func newTestTUI() *tuiModel {
+	sites := []site.Site{
+		{Name: "github.com", Length: 20, Level: passgen.LevelStrong, Counter: 3, AlgorithmVersion: 1},
+		{Name: "gitlab.com", Length: 16, Level: passgen.LevelLow, Counter: 1, AlgorithmVersion: 1},
+	}
+	return newTUIModel(sites, "salt", "boot-agent-grape", tuiParams{length: 64, level: passgen.LevelMedium, counter: 1, version: 1})
+}
+
+func TestTUIModel_Selection(t *testing.T) {
+	m := newTestTUI()
+	for _, r := range "gitl" {
+		m.handle(key{code: keyRune, r: r})
+	}
+	if m.input() != "gitlab.com" || m.params.length != 16 {
+		t.Fatalf("after typing, input = %q length = %d, want gitlab.com with its stored length", m.input(), m.params.length)
+	}
+
+	m.handle(key{code: keyRight})
+	m.handle(key{code: keyPgUp})
+	m.handle(key{code: keyTab})
+	if p := m.params; p.length != 17 || p.counter != 2 || p.level != passgen.LevelMedium {
+		t.Errorf("params = %+v, want length 17, counter 2, level medium", p)
+	}
+
+	m.handle(key{code: keyCtrl, r: 'u'})
+	m.handle(key{code: keyRune, r: 'z'})
+	if m.input() != "z" || m.params.length != 64 || m.current != "" {
+		t.Errorf("with no match, input = %q params = %+v, want the query and the defaults", m.input(), m.params)
+	}
+
+	for range 3 {
+		m.handle(key{code: keyCtrlLeft})
+	}
+	if m.params.length != 34 {
+		t.Errorf("length = %d, want 34", m.params.length)
+	}
+	for range 10 {
+		m.handle(key{code: keyCtrlLeft})
+	}
+	if m.params.length != 1 {
+		t.Errorf("length = %d, want it clamped to 1", m.params.length)
+	}
+}
+
+func TestTUIModel_View(t *testing.T) {
+	m := newTestTUI()
+	want, _ := passgen.Generate(passgen.Config{Input: "github.com", Salt: "salt", Length: 20, Level: passgen.LevelStrong, Counter: 3, Version: 1})
+
+	screen := strings.Join(m.view(80, 24), "\n")
+	if strings.Contains(screen, want) {
+		t.Error("the password should be masked until revealed")
+	}
+	if !strings.Contains(screen, strings.Repeat("*", 20)) || !strings.Contains(screen, "Entropy   127.50 bits") {
+		t.Errorf("view() =\n%s", screen)
+	}
+
+	if m.handle(key{code: keyCtrl, r: 'r'}) != tuiNone {
+		t.Error("Ctrl-R should not leave the interface")
+	}
+	if screen := strings.Join(m.view(80, 24), "\n"); !strings.Contains(screen, want) {
+		t.Errorf("revealed view() =\n%s\nwant the password %s", screen, want)
+	}
+
+	for _, line := range m.view(30, 10) {
+		if len([]rune(line)) > 30 {
+			t.Errorf("line %q is wider than the terminal", line)
+		}
+	}
+	if m.handle(key{code: keyEnter}) != tuiCopyQuit || m.handle(key{code: keyEsc}) != tuiQuit {
+		t.Error("Enter should copy and quit, Esc should quit")
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/vault"
+)
+
+type vaultOptions struct {
+	path         string
+	passphraseFD int
+}
+
+func (o *vaultOptions) register(fs *flagSet) {
+	fs.StringVar(&o.path, "vault", "", "PATH", "", "Vault file (default: $XDG_DATA_HOME/passgen/vault)")
+	fs.IntVar(&o.passphraseFD, "passphrase-fd", "", "FD", -1, "Read the vault passphrase from file descriptor FD")
+}
+
+func (o vaultOptions) file() string {
+	if o.path != "" {
+		return o.path
+	}
+	return vault.DefaultPath()
+}
+
+// passphrase reads the vault passphrase from --passphrase-fd or the terminal.
+func (o vaultOptions) passphrase() (string, error) {
+	if o.passphraseFD >= 0 {
+		s, err := readLineFD(o.passphraseFD)
+		if err != nil {
+			return "", fmt.Errorf("reading passphrase from fd %d: %v", o.passphraseFD, err)
+		}
+		return s, nil
+	}
+	return promptSecret("Vault passphrase: ")
+}
+
+func (o vaultOptions) open() (*vault.Vault, error) {
+	path := o.file()
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no vault at %s (create one with '%s vault init')", path, progName)
+	}
+	pass, err := o.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	return vault.Open(path, pass)
+}
+
+// newSecret reads a new secret from fd, or prompts for it twice.
+func newSecret(fd int, label string) (string, error) {
+	if fd >= 0 {
+		return readLineFD(fd)
+	}
+	s, err := promptSecret(label + ": ")
+	if err != nil {
+		return "", err
+	}
+	again, err := promptSecret("Confirm " + strings.ToLower(label) + ": ")
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare([]byte(s), []byte(again)) != 1 {
+		return "", errors.New("entries do not match")
+	}
+	return s, nil
+}
+
+func singleEntryArg(fs *flagSet) (string, error) {
+	switch len(fs.Args()) {
+	case 0:
+		return "", errors.New("entry name is required")
+	case 1:
+		return fs.Args()[0], nil
+	}
+	return "", fmt.Errorf("unexpected argument %q", fs.Args()[1])
+}
+
+var vaultInitCommand = &command{
+	name:    "vault init",
+	usage:   "[OPTIONS]",
+	summary: "Create an empty encrypted vault for salts and inputs",
+	env:     []envVar{envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var opts vaultOptions
+		opts.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			path := opts.file()
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("a vault already exists at %s", path)
+			}
+
+			pass, err := newSecret(opts.passphraseFD, "Vault passphrase")
+			if err != nil {
+				return err
+			}
+			v, err := vault.New(pass)
+			if err != nil {
+				return err
+			}
+			if err := v.Save(path); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Created vault %s\n", path)
+			return nil
+		}
+	},
+}
+
+var vaultAddCommand = &command{
+	name:    "vault add",
+	usage:   "[OPTIONS] NAME",
+	summary: "Store a salt or input in the vault",
+	details: "The value is prompted for twice without echo, read from --value-fd, or\ngenerated with --random. Use it later with --salt-ref NAME or --input-ref NAME.",
+	examples: []example{
+		{"Store a new random salt and use it", "passgen vault add --random work && passgen gen -i github.com --salt-ref work"},
+	},
+	env: []envVar{envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var kind string
+		var valueFD int
+		var random, force bool
+		var opts vaultOptions
+
+		fs.StringVar(&kind, "kind", "k", "KIND", string(vault.KindSalt), "Entry kind: salt or input")
+		fs.IntVar(&valueFD, "value-fd", "", "FD", -1, "Read the value from file descriptor FD")
+		fs.BoolVar(&random, "random", "", "Generate a random value")
+		fs.BoolVar(&force, "force", "f", "Replace an existing entry")
+		opts.register(fs)
+
+		return func() error {
+			name, err := singleEntryArg(fs)
+			if err != nil {
+				return err
+			}
+			if kind != string(vault.KindSalt) && kind != string(vault.KindInput) {
+				return fmt.Errorf("invalid kind %q (use salt or input)", kind)
+			}
+			if random && valueFD >= 0 {
+				return errors.New("--random and --value-fd are mutually exclusive")
+			}
+
+			v, err := opts.open()
+			if err != nil {
+				return err
+			}
+			if _, err := v.Get(name); err == nil && !force {
+				return fmt.Errorf("vault entry %q already exists (use --force to replace it)", name)
+			}
+
+			var value string
+			if random {
+				value, err = passgen.GenerateRandomString(passgen.DefaultSaltLength)
+			} else {
+				value, err = newSecret(valueFD, strings.ToUpper(kind[:1])+kind[1:])
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := v.Put(name, vault.Entry{Kind: vault.Kind(kind), Value: value}); err != nil {
+				return err
+			}
+			return v.Save(opts.file())
+		}
+	},
+}
+
+var vaultRmCommand = &command{
+	name:    "vault rm",
+	usage:   "[OPTIONS] NAME",
+	summary: "Remove an entry from the vault",
+	env:     []envVar{envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var opts vaultOptions
+		opts.register(fs)
+
+		return func() error {
+			name, err := singleEntryArg(fs)
+			if err != nil {
+				return err
+			}
+			v, err := opts.open()
+			if err != nil {
+				return err
+			}
+			if err := v.Remove(name); err != nil {
+				return err
+			}
+			return v.Save(opts.file())
+		}
+	},
+}
+
+var vaultUnlockCommand = &command{
+	name:    "vault unlock",
+	usage:   "[OPTIONS] [NAME]",
+	summary: "Decrypt the vault and list its entries, or print one value",
+	env:     []envVar{envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var opts vaultOptions
+		opts.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 1 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[1])
+			}
+			v, err := opts.open()
+			if err != nil {
+				return err
+			}
+
+			if len(fs.Args()) == 1 {
+				e, err := v.Get(fs.Args()[0])
+				if err != nil {
+					return err
+				}
+				fmt.Println(e.Value)
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tKIND\tCREATED")
+			for _, name := range v.Names() {
+				e, _ := v.Get(name)
+				fmt.Fprintf(w, "%s\t%s\t%s\n", name, e.Kind, e.Created.Format(time.DateOnly))
+			}
+			return w.Flush()
+		}
+	},
+}
+
+var vaultPasswdCommand = &command{
+	name:    "vault passwd",
+	usage:   "[OPTIONS]",
+	summary: "Change the vault passphrase and re-encrypt every entry",
+	env:     []envVar{envDataHome},
+	setup: func(fs *flagSet) func() error {
+		var newFD int
+		var opts vaultOptions
+
+		fs.IntVar(&newFD, "new-passphrase-fd", "", "FD", -1, "Read the new passphrase from file descriptor FD")
+		opts.register(fs)
+
+		return func() error {
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			v, err := opts.open()
+			if err != nil {
+				return err
+			}
+
+			pass, err := newSecret(newFD, "New vault passphrase")
+			if err != nil {
+				return err
+			}
+			if err := v.SetPassphrase(pass); err != nil {
+				return err
+			}
+			return v.Save(opts.file())
+		}
+	},
+}
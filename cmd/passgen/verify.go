@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+var verifyCommand = &command{
+	name:    "verify",
+	usage:   "[OPTIONS] [< candidate]",
+	summary: "Check a candidate password read from stdin without printing it",
+	details: "The candidate is prompted for without echo when stdin is a terminal.\nExits 0 when the candidate matches and 1 otherwise. With --search-counters or\n--search-levels every matching combination is reported.",
+	examples: []example{
+		{"Check a password without showing it", "passgen verify -i github.com -l 20 -L strong < candidate.txt"},
+		{"Find the counter of an old password", "passgen verify -i github.com --search-counters 10"},
+	},
+	env:         []envVar{envSalt, envAgentSock, envConfigHome},
+	envDefaults: slices.Concat(envGenerator, envFiles, []string{"format"}),
+	setup: func(fs *flagSet) func() error {
+		var input, salt, level, encoding string
+		var length, counter, searchCounters, algoVersion int
+		var searchLevels bool
+		var secrets secretOptions
+		var output outputOptions
+		var config configOptions
+
+		fs.StringVar(&input, "input", "i", "TEXT", "", "Input string (required)")
+		fs.StringVar(&salt, "salt", "s", "TEXT", "", "Salt string (or PASSGEN_SALT)")
+		fs.IntVar(&length, "length", "l", "NUM", 0, "Password length (default: length of the candidate)")
+		fs.StringVar(&level, "level", "L", "LEVEL", "medium", "Security level: low, medium, strong")
+		fs.IntVar(&counter, "counter", "c", "NUM", 1, "Password counter")
+		fs.StringVar(&encoding, "encoding", "", "ENC", "", "Output encoding (length in bytes)")
+		fs.IntVar(&searchCounters, "search-counters", "", "NUM", 0, "Try counters 1..NUM and report matches")
+		fs.BoolVar(&searchLevels, "search-levels", "", "Try every security level and report matches")
+		fs.IntVar(&algoVersion, "algo-version", "", "NUM", passgen.AlgorithmVersion, "Generation algorithm version")
+		config.register(fs)
+		secrets.register(fs)
+		output.register(fs)
+
+		return func() error {
+			if err := config.apply(fs); err != nil {
+				return err
+			}
+			if len(fs.Args()) > 0 {
+				return fmt.Errorf("unexpected argument %q", fs.Args()[0])
+			}
+			if err := output.validate(); err != nil {
+				return err
+			}
+			if err := secrets.resolveInput(&input); err != nil {
+				return err
+			}
+			if input == "" {
+				return errors.New("input is required (-i, --input, --input-stdin or --prompt)")
+			}
+			if err := secrets.resolveSalt(&salt); err != nil {
+				return err
+			}
+			if searchCounters < 0 || searchCounters > passgen.MaxSearchCounter {
+				return fmt.Errorf("--search-counters must be between 0 and %d", passgen.MaxSearchCounter)
+			}
+
+			candidate, err := readCandidate()
+			if err != nil {
+				return fmt.Errorf("reading candidate: %v", err)
+			}
+
+			cfg := passgen.Config{
+				Input:    input,
+				Salt:     salt,
+				Length:   length,
+				Level:    passgen.Level(level),
+				Encoding: passgen.Encoding(encoding),
+				Counter:  counter,
+				Version:  algoVersion,
+			}
+
+			var space passgen.SearchSpace
+			if searchLevels {
+				space.Levels = []passgen.Level{passgen.LevelLow, passgen.LevelMedium, passgen.LevelStrong}
+			}
+			for c := 1; c <= searchCounters; c++ {
+				space.Counters = append(space.Counters, c)
+			}
+
+			matches, err := passgen.VerifySearch(cfg, candidate, space)
+			if err != nil {
+				return err
+			}
+
+			searched := len(space.Levels) > 0 || len(space.Counters) > 0
+			if output.structured() {
+				r := newRecord("verify").with("match", len(matches) > 0)
+				if searched {
+					found := make([]verifyMatch, 0, len(matches))
+					for _, m := range matches {
+						found = append(found, verifyMatch{Level: string(m.Level), Encoding: string(m.Encoding), Length: m.Length, Counter: max(m.Counter, 1)})
+					}
+					r = r.with("matches", found)
+				}
+				if err := output.print(r); err != nil {
+					return err
+				}
+				if len(matches) == 0 {
+					return exitCode(1)
+				}
+				return nil
+			}
+
+			if len(matches) == 0 {
+				fmt.Fprintln(os.Stderr, "no match")
+				return exitCode(1)
+			}
+
+			if !searched {
+				fmt.Fprintln(os.Stderr, "match")
+				return nil
+			}
+			for _, m := range matches {
+				fmt.Printf("match: level=%s length=%d counter=%d\n", m.Level, m.Length, max(m.Counter, 1))
+			}
+			return nil
+		}
+	},
+}
+
+type verifyMatch struct {
+	Level    string `json:"level,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Length   int    `json:"length"`
+	Counter  int    `json:"counter"`
+}
+
+// readCandidate prompts without echo when stdin is a terminal and reads the
+// next line of stdin otherwise, after any --input-stdin line.
+func readCandidate() (string, error) {
+	if isTerminal(os.Stdin) {
+		return promptSecret("Candidate: ")
+	}
+	return readLineFD(0)
+}
@@ -0,0 +1,199 @@
+// Package clipboard copies secrets to the system clipboard through external
+// tools (wl-copy, xclip, xsel) or OSC 52 terminal escape sequences.
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var ErrUnsupported = errors.New("clipboard backend cannot read the clipboard")
+
+type Clipboard interface {
+	Name() string
+	Copy(text string) error
+	Paste() (string, error)
+	Clear() error
+}
+
+type command struct {
+	name  string
+	env   string
+	copy  []string
+	paste []string
+	clear []string
+}
+
+var commands = []*command{
+	{
+		name:  "wl-copy",
+		env:   "WAYLAND_DISPLAY",
+		copy:  []string{"wl-copy"},
+		paste: []string{"wl-paste", "--no-newline"},
+		clear: []string{"wl-copy", "--clear"},
+	},
+	{
+		name:  "xclip",
+		env:   "DISPLAY",
+		copy:  []string{"xclip", "-selection", "clipboard"},
+		paste: []string{"xclip", "-selection", "clipboard", "-o"},
+	},
+	{
+		name:  "xsel",
+		env:   "DISPLAY",
+		copy:  []string{"xsel", "--clipboard", "--input"},
+		paste: []string{"xsel", "--clipboard", "--output"},
+		clear: []string{"xsel", "--clipboard", "--clear"},
+	},
+}
+
+// Backends lists the names accepted by New.
+func Backends() []string {
+	names := make([]string, 0, len(commands)+1)
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return append(names, "osc52")
+}
+
+// New returns the named backend. An empty name or "auto" picks the first
+// command backend whose display variable is set and whose tool is on PATH,
+// falling back to OSC 52 on the controlling terminal.
+func New(name string) (Clipboard, error) {
+	switch name {
+	case "osc52":
+		return openOSC52()
+	case "", "auto":
+		for _, c := range commands {
+			if os.Getenv(c.env) != "" && c.available() {
+				return c, nil
+			}
+		}
+		return openOSC52()
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			if !c.available() {
+				return nil, fmt.Errorf("clipboard backend %s: %s not found in PATH", name, c.copy[0])
+			}
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown clipboard backend %q (use %s)", name, strings.Join(Backends(), ", "))
+}
+
+func (c *command) Name() string {
+	return c.name
+}
+
+func (c *command) available() bool {
+	_, err := exec.LookPath(c.copy[0])
+	return err == nil
+}
+
+func (c *command) Copy(text string) error {
+	return c.run(c.copy, text)
+}
+
+func (c *command) Paste() (string, error) {
+	if _, err := exec.LookPath(c.paste[0]); err != nil {
+		return "", ErrUnsupported
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(c.paste[0], c.paste[1:]...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %v", c.paste[0], err)
+	}
+	return out.String(), nil
+}
+
+func (c *command) Clear() error {
+	if c.clear == nil {
+		return c.Copy("")
+	}
+	return c.run(c.clear, "")
+}
+
+// stderrDelay bounds how long run waits for stderr after the tool exits.
+// xclip and wl-copy fork a process that keeps serving the selection and
+// inherits the pipe, which would otherwise block until the selection changes.
+const stderrDelay = 100 * time.Millisecond
+
+func (c *command) run(argv []string, stdin string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = stderrDelay
+	if err := cmd.Run(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %v: %s", argv[0], err, msg)
+		}
+		return fmt.Errorf("%s: %v", argv[0], err)
+	}
+	return nil
+}
+
+type osc52 struct {
+	w io.Writer
+}
+
+// NewOSC52 returns a backend that asks the terminal behind w to set its
+// clipboard. It works over SSH and inside tmux with set-clipboard enabled,
+// but cannot read the clipboard back.
+func NewOSC52(w io.Writer) Clipboard {
+	return &osc52{w: w}
+}
+
+func openOSC52() (Clipboard, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no clipboard tool found and no terminal for OSC 52: %v", err)
+	}
+	return NewOSC52(tty), nil
+}
+
+func (o *osc52) Name() string {
+	return "osc52"
+}
+
+func (o *osc52) Copy(text string) error {
+	_, err := fmt.Fprintf(o.w, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+func (o *osc52) Paste() (string, error) {
+	return "", ErrUnsupported
+}
+
+// Clear sends a payload that is not valid base64, which terminals treat as a
+// request to clear the selection.
+func (o *osc52) Clear() error {
+	_, err := io.WriteString(o.w, "\x1b]52;c;!\a")
+	return err
+}
+
+// Restore undoes a Copy of secret. If the clipboard no longer holds secret the
+// user has copied something else and it is left alone; otherwise previous is
+// put back, or the clipboard is cleared when there was nothing to restore.
+func Restore(cb Clipboard, secret, previous string, hadPrevious bool) error {
+	current, err := cb.Paste()
+	if err == nil && current != secret {
+		return nil
+	}
+	if hadPrevious && previous != "" {
+		return cb.Copy(previous)
+	}
+	return cb.Clear()
+}
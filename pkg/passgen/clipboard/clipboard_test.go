@@ -0,0 +1,159 @@
+package clipboard
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// fakeWayland installs stub wl-copy and wl-paste binaries that keep the
+// clipboard in a file, and returns that file's path.
+func fakeWayland(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake clipboard binaries need a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	store := filepath.Join(dir, "clipboard")
+
+	scripts := map[string]string{
+		"wl-copy":  "#!/bin/sh\nif [ \"$1\" = --clear ]; then : > \"$STORE\"; else /bin/cat > \"$STORE\"; fi\n",
+		"wl-paste": "#!/bin/sh\n[ -f \"$STORE\" ] && /bin/cat \"$STORE\"\nexit 0\n",
+	}
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("PATH", dir)
+	t.Setenv("STORE", store)
+	t.Setenv("WAYLAND_DISPLAY", "wayland-test")
+	return store
+}
+
+func TestNew_AutoDetectsWayland(t *testing.T) {
+	fakeWayland(t)
+
+	cb, err := New("auto")
+	if err != nil {
+		t.Fatalf("New(auto) error = %v", err)
+	}
+	if cb.Name() != "wl-copy" {
+		t.Errorf("New(auto) = %s, want wl-copy", cb.Name())
+	}
+}
+
+func TestCommand_CopyPasteClear(t *testing.T) {
+	store := fakeWayland(t)
+
+	cb, err := New("wl-copy")
+	if err != nil {
+		t.Fatalf("New(wl-copy) error = %v", err)
+	}
+
+	if err := cb.Copy("s3cret"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if got, _ := os.ReadFile(store); string(got) != "s3cret" {
+		t.Errorf("clipboard = %q, want s3cret", got)
+	}
+	if got, err := cb.Paste(); err != nil || got != "s3cret" {
+		t.Errorf("Paste() = %q, %v, want s3cret", got, err)
+	}
+
+	if err := cb.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if got, _ := os.ReadFile(store); len(got) != 0 {
+		t.Errorf("clipboard after Clear() = %q, want empty", got)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	store := fakeWayland(t)
+	cb, _ := New("wl-copy")
+
+	cb.Copy("previous")
+	cb.Copy("s3cret")
+	if err := Restore(cb, "s3cret", "previous", true); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got, _ := os.ReadFile(store); string(got) != "previous" {
+		t.Errorf("clipboard after Restore() = %q, want previous", got)
+	}
+
+	cb.Copy("s3cret")
+	Restore(cb, "s3cret", "", false)
+	if got, _ := os.ReadFile(store); len(got) != 0 {
+		t.Errorf("clipboard after Restore() without previous = %q, want empty", got)
+	}
+
+	cb.Copy("copied later")
+	Restore(cb, "s3cret", "previous", true)
+	if got, _ := os.ReadFile(store); string(got) != "copied later" {
+		t.Errorf("Restore() should keep newer contents, got %q", got)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if _, err := New("xclip"); err == nil {
+		t.Error("New(xclip) should fail when xclip is not installed")
+	}
+	if _, err := New("pbcopy-ish"); err == nil {
+		t.Error("New() should reject unknown backends")
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	var buf bytes.Buffer
+	cb := NewOSC52(&buf)
+
+	if err := cb.Copy("hi"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if got := buf.String(); got != "\x1b]52;c;aGk=\a" {
+		t.Errorf("Copy() wrote %q", got)
+	}
+
+	buf.Reset()
+	cb.Clear()
+	if got := buf.String(); got != "\x1b]52;c;!\a" {
+		t.Errorf("Clear() wrote %q", got)
+	}
+
+	if _, err := cb.Paste(); err != ErrUnsupported {
+		t.Errorf("Paste() error = %v, want ErrUnsupported", err)
+	}
+}
+
+// wl-copy and xclip leave a process behind that holds the selection, and with
+// it the stderr they inherited. Copy must not wait for it.
+func TestCommand_CopyForks(t *testing.T) {
+	store := fakeWayland(t)
+	script := "#!/bin/sh\n/bin/cat > \"$STORE\"\n/bin/sleep 3 &\n"
+	if err := os.WriteFile(filepath.Join(filepath.Dir(store), "wl-copy"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cb, err := New("wl-copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := cb.Copy("s3cret"); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Copy() took %v, want it to return when wl-copy exits", d)
+	}
+	if got, _ := os.ReadFile(store); string(got) != "s3cret" {
+		t.Errorf("clipboard = %q, want s3cret", got)
+	}
+}
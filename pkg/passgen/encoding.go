@@ -0,0 +1,144 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+type Encoding string
+
+const (
+	EncodingNone      Encoding = ""
+	EncodingHex       Encoding = "hex"
+	EncodingBase32    Encoding = "base32"
+	EncodingCrockford Encoding = "crockford"
+	EncodingBase58    Encoding = "base58"
+	EncodingBase64URL Encoding = "base64url"
+	EncodingZ85       Encoding = "z85"
+)
+
+var Encodings = []Encoding{
+	EncodingHex,
+	EncodingBase32,
+	EncodingCrockford,
+	EncodingBase58,
+	EncodingBase64URL,
+	EncodingZ85,
+}
+
+const (
+	base58Alphabet    = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	z85Alphabet       = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+)
+
+var (
+	base32NoPad    = base32.StdEncoding.WithPadding(base32.NoPadding)
+	crockfordNoPad = base32.NewEncoding(crockfordAlphabet).WithPadding(base32.NoPadding)
+)
+
+// Encode renders b in the given encoding. Base32, Crockford and base64url
+// output is unpadded; Z85 requires len(b) to be a multiple of 4.
+func Encode(b []byte, enc Encoding) (string, error) {
+	switch enc {
+	case EncodingHex:
+		return hex.EncodeToString(b), nil
+	case EncodingBase32:
+		return base32NoPad.EncodeToString(b), nil
+	case EncodingCrockford:
+		return crockfordNoPad.EncodeToString(b), nil
+	case EncodingBase58:
+		return encodeBase58(b), nil
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.EncodeToString(b), nil
+	case EncodingZ85:
+		return encodeZ85(b)
+	default:
+		return "", errors.New("invalid encoding")
+	}
+}
+
+func GenerateRandomEncoded(n int, enc Encoding) (string, error) {
+	if err := validateEncodedLength(n, enc); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encode(b, enc)
+}
+
+func generateEncoded(cfg Config) (string, error) {
+	if err := validateEncodedLength(cfg.Length, cfg.Encoding); err != nil {
+		return "", err
+	}
+
+	rng := newDetermRNG("bytes\x00" + cfg.Salt + "\x00" + cfg.Input + "\x00" + strconv.Itoa(cfg.Length) + counterSuffix(cfg.Counter))
+
+	b := make([]byte, cfg.Length)
+	for i := range b {
+		b[i] = rng.nextByte()
+	}
+	return Encode(b, cfg.Encoding)
+}
+
+func validateEncodedLength(n int, enc Encoding) error {
+	if n <= 0 || n > 4096 {
+		return errors.New("length must be positive and not exceed 4096")
+	}
+	if enc == EncodingZ85 && n%4 != 0 {
+		return errors.New("z85 length must be a multiple of 4 bytes")
+	}
+	return nil
+}
+
+func encodeBase58(b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+
+	num := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	out := make([]byte, 0, len(b)*138/100+1)
+	for num.Sign() > 0 {
+		num.DivMod(num, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for range zeros {
+		out = append(out, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func encodeZ85(b []byte) (string, error) {
+	if len(b)%4 != 0 {
+		return "", errors.New("z85 input length must be a multiple of 4")
+	}
+
+	out := make([]byte, 0, len(b)/4*5)
+	for i := 0; i < len(b); i += 4 {
+		v := uint32(b[i])<<24 | uint32(b[i+1])<<16 | uint32(b[i+2])<<8 | uint32(b[i+3])
+
+		var chunk [5]byte
+		for j := 4; j >= 0; j-- {
+			chunk[j] = z85Alphabet[v%85]
+			v /= 85
+		}
+		out = append(out, chunk[:]...)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,122 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncode_KnownVectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		enc   Encoding
+		want  string
+	}{
+		{"hex", []byte{0xde, 0xad, 0xbe, 0xef}, EncodingHex, "deadbeef"},
+		{"base32", []byte("foobar"), EncodingBase32, "MZXW6YTBOI"},
+		{"crockford", []byte("foobar"), EncodingCrockford, "CSQPYRK1E8"},
+		{"base58", []byte("Hello World!"), EncodingBase58, "2NEpo7TZRRrLZSi2U"},
+		{"base58 leading zeros", []byte{0, 0, 1}, EncodingBase58, "112"},
+		{"base64url", []byte{0xfb, 0xff, 0xbf}, EncodingBase64URL, "-_-_"},
+		{"z85", []byte{0x86, 0x4F, 0xD2, 0x6F, 0xB5, 0x59, 0xF7, 0x5B}, EncodingZ85, "HelloWorld"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(tt.input, tt.enc)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode_Invalid(t *testing.T) {
+	if _, err := Encode([]byte{1}, "base99"); err == nil {
+		t.Error("Encode() should reject unknown encoding")
+	}
+	if _, err := Encode([]byte{1, 2, 3}, EncodingZ85); err == nil {
+		t.Error("Encode() should reject z85 input not a multiple of 4")
+	}
+}
+
+func TestGenerate_Encoding(t *testing.T) {
+	for _, enc := range Encodings {
+		t.Run(string(enc), func(t *testing.T) {
+			cfg := Config{Input: "key", Salt: "salt", Length: 32, Encoding: enc}
+
+			r1, err := Generate(cfg)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			r2, _ := Generate(cfg)
+			if r1 != r2 {
+				t.Errorf("Generate() not deterministic: %q vs %q", r1, r2)
+			}
+
+			cfg.Salt = "other"
+			r3, _ := Generate(cfg)
+			if r1 == r3 {
+				t.Error("Different salts should produce different output")
+			}
+		})
+	}
+}
+
+func TestGenerate_EncodingLength(t *testing.T) {
+	cfg := Config{Input: "key", Salt: "salt", Length: 32, Encoding: EncodingHex}
+	got, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(got) != 64 {
+		t.Errorf("hex output length = %d, want 64", len(got))
+	}
+
+	cfg.Encoding = EncodingZ85
+	cfg.Length = 30
+	if _, err := Generate(cfg); err == nil {
+		t.Error("Generate() should reject z85 length not a multiple of 4")
+	}
+}
+
+func TestGenerate_EncodingIgnoresLevel(t *testing.T) {
+	a, err := Generate(Config{Input: "key", Length: 16, Encoding: EncodingHex})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	b, _ := Generate(Config{Input: "key", Length: 16, Level: LevelStrong, Encoding: EncodingHex})
+	if a != b {
+		t.Errorf("Level should not affect encoded output: %q vs %q", a, b)
+	}
+}
+
+func TestGenerateRandomEncoded(t *testing.T) {
+	for _, enc := range Encodings {
+		r1, err := GenerateRandomEncoded(32, enc)
+		if err != nil {
+			t.Fatalf("GenerateRandomEncoded(%s) error = %v", enc, err)
+		}
+		r2, _ := GenerateRandomEncoded(32, enc)
+		if r1 == r2 {
+			t.Errorf("GenerateRandomEncoded(%s) produced duplicate output", enc)
+		}
+	}
+
+	got, _ := GenerateRandomEncoded(16, EncodingCrockford)
+	for _, r := range got {
+		if !strings.ContainsRune(crockfordAlphabet, r) {
+			t.Errorf("crockford output contains %c", r)
+		}
+	}
+
+	if _, err := GenerateRandomEncoded(0, EncodingHex); err == nil {
+		t.Error("GenerateRandomEncoded(0) should return error")
+	}
+	if _, err := GenerateRandomEncoded(4097, EncodingHex); err == nil {
+		t.Error("GenerateRandomEncoded(4097) should return error")
+	}
+}
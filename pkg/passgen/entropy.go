@@ -0,0 +1,43 @@
+package passgen
+
+import (
+	"errors"
+	"math"
+)
+
+// Entropy estimates the strength of the output space of cfg in bits. It is an
+// upper bound: a deterministic password is never stronger than the input and
+// salt it was derived from.
+func Entropy(cfg Config) (float64, error) {
+	if cfg.Length <= 0 {
+		return 0, errors.New("length must be positive")
+	}
+	if cfg.Encoding != EncodingNone {
+		return 8 * float64(cfg.Length), nil
+	}
+
+	size := 0
+	switch cfg.Level {
+	case LevelLow:
+		size = len(charsLower)
+	case LevelMedium:
+		size = len(charsLower) + len(charsUpper) + len(charsDigits)
+	case LevelStrong:
+		size = len(charsLower) + len(charsUpper) + len(charsDigits) + len(charsSpecial)
+	default:
+		return 0, errors.New("invalid level")
+	}
+
+	return float64(cfg.Length) * math.Log2(float64(size)), nil
+}
+
+func RandomStringEntropy(length int) float64 {
+	if length <= 0 {
+		length = DefaultSaltLength
+	}
+	return float64(length) * math.Log2(float64(len(randomCharset)))
+}
+
+func (f RecoveryFormat) Entropy() float64 {
+	return float64(f.Groups*f.GroupSize) * math.Log2(float64(len(recoveryCharset)))
+}
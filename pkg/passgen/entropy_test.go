@@ -0,0 +1,54 @@
+package passgen
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want float64
+	}{
+		{Config{Length: 10, Level: LevelLow}, 10 * math.Log2(26)},
+		{Config{Length: 16, Level: LevelMedium}, 16 * math.Log2(62)},
+		{Config{Length: 20, Level: LevelStrong}, 20 * math.Log2(float64(62+len(charsSpecial)))},
+		{Config{Length: 32, Encoding: EncodingHex}, 256},
+		{Config{Length: 32, Level: LevelLow, Encoding: EncodingBase58}, 256},
+	}
+
+	for _, tt := range tests {
+		got, err := Entropy(tt.cfg)
+		if err != nil {
+			t.Fatalf("Entropy(%+v) error = %v", tt.cfg, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Entropy(%+v) = %f, want %f", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestEntropy_Invalid(t *testing.T) {
+	if _, err := Entropy(Config{Length: 0, Level: LevelLow}); err == nil {
+		t.Error("Entropy() should reject zero length")
+	}
+	if _, err := Entropy(Config{Length: 8, Level: "bogus"}); err == nil {
+		t.Error("Entropy() should reject invalid level")
+	}
+}
+
+func TestRandomStringEntropy(t *testing.T) {
+	if got, want := RandomStringEntropy(10), 10*math.Log2(62); math.Abs(got-want) > 1e-9 {
+		t.Errorf("RandomStringEntropy(10) = %f, want %f", got, want)
+	}
+	if RandomStringEntropy(0) != RandomStringEntropy(DefaultSaltLength) {
+		t.Error("RandomStringEntropy(0) should use DefaultSaltLength")
+	}
+}
+
+func TestRecoveryFormat_Entropy(t *testing.T) {
+	got := DefaultRecoveryFormat.Entropy()
+	if want := 8 * math.Log2(31); math.Abs(got-want) > 1e-9 {
+		t.Errorf("DefaultRecoveryFormat.Entropy() = %f, want %f", got, want)
+	}
+}
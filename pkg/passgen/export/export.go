@@ -0,0 +1,206 @@
+// Package export writes derived credentials in the import formats of other
+// password managers. The output holds every password in plain text.
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format names an import format.
+type Format string
+
+const (
+	// KeePassXML is the KeePass 2.x XML file, imported by KeePass with
+	// File > Import > KeePass XML (2.x).
+	KeePassXML Format = "keepass-xml"
+	// BitwardenJSON is Bitwarden's unencrypted JSON export.
+	BitwardenJSON Format = "bitwarden-json"
+	// OnePasswordCSV is the CSV file with a Title, Website, Username,
+	// Password and Notes header accepted by 1Password's importer.
+	OnePasswordCSV Format = "1password-csv"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{KeePassXML, BitwardenJSON, OnePasswordCSV}
+
+// Entry is one credential.
+type Entry struct {
+	Title    string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+}
+
+// URL guesses the address of a site from its name. Names with a scheme are
+// kept, names that look like host names get https://, and anything else has
+// no URL.
+func URL(name string) string {
+	if strings.Contains(name, "://") {
+		return name
+	}
+	if !strings.Contains(name, ".") || strings.ContainsAny(name, " \t") {
+		return ""
+	}
+	return "https://" + name
+}
+
+// Write encodes entries to w in format f.
+func Write(w io.Writer, f Format, entries []Entry) error {
+	switch f {
+	case KeePassXML:
+		return writeKeePass(w, entries)
+	case BitwardenJSON:
+		return writeBitwarden(w, entries)
+	case OnePasswordCSV:
+		return writeOnePassword(w, entries)
+	}
+	return fmt.Errorf("unknown export format %q", f)
+}
+
+type keePassString struct {
+	Key   string       `xml:"Key"`
+	Value keePassValue `xml:"Value"`
+}
+
+type keePassValue struct {
+	Protect string `xml:"ProtectInMemory,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+type keePassEntry struct {
+	UUID    string          `xml:"UUID"`
+	Strings []keePassString `xml:"String"`
+}
+
+type keePassFile struct {
+	XMLName   xml.Name `xml:"KeePassFile"`
+	Generator string   `xml:"Meta>Generator"`
+	Group     struct {
+		UUID    string         `xml:"UUID"`
+		Name    string         `xml:"Name"`
+		Entries []keePassEntry `xml:"Entry"`
+	} `xml:"Root>Group"`
+}
+
+// keePassUUID derives a stable entry UUID from name, so that exporting again
+// produces the same file and KeePass can match entries on re-import.
+func keePassUUID(name string) string {
+	sum := sha256.Sum256([]byte("passgen\x00" + name))
+	return base64.StdEncoding.EncodeToString(sum[:16])
+}
+
+func writeKeePass(w io.Writer, entries []Entry) error {
+	var doc keePassFile
+	doc.Generator = "passgen"
+	doc.Group.UUID = keePassUUID("")
+	doc.Group.Name = "passgen"
+	for _, e := range entries {
+		doc.Group.Entries = append(doc.Group.Entries, keePassEntry{
+			UUID: keePassUUID(e.Title),
+			Strings: []keePassString{
+				{Key: "Title", Value: keePassValue{Text: e.Title}},
+				{Key: "UserName", Value: keePassValue{Text: e.Username}},
+				{Key: "Password", Value: keePassValue{Protect: "True", Text: e.Password}},
+				{Key: "URL", Value: keePassValue{Text: e.URL}},
+				{Key: "Notes", Value: keePassValue{Text: e.Notes}},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, `<?xml version="1.0" encoding="utf-8" standalone="yes"?>`+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type bitwardenFile struct {
+	Encrypted bool            `json:"encrypted"`
+	Folders   []struct{}      `json:"folders"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+type bitwardenItem struct {
+	Type     int            `json:"type"`
+	Name     string         `json:"name"`
+	Notes    *string        `json:"notes"`
+	Favorite bool           `json:"favorite"`
+	FolderID *string        `json:"folderId"`
+	Reprompt int            `json:"reprompt"`
+	Login    bitwardenLogin `json:"login"`
+}
+
+type bitwardenLogin struct {
+	URIs     []bitwardenURI `json:"uris"`
+	Username *string        `json:"username"`
+	Password string         `json:"password"`
+	TOTP     *string        `json:"totp"`
+}
+
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+// bitwardenLoginType is the item type of a login in Bitwarden exports.
+const bitwardenLoginType = 1
+
+// optional maps "" to a JSON null, as Bitwarden's own exports do.
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func writeBitwarden(w io.Writer, entries []Entry) error {
+	doc := bitwardenFile{Folders: []struct{}{}, Items: []bitwardenItem{}}
+	for _, e := range entries {
+		item := bitwardenItem{
+			Type:  bitwardenLoginType,
+			Name:  e.Title,
+			Notes: optional(e.Notes),
+			Login: bitwardenLogin{
+				URIs:     []bitwardenURI{},
+				Username: optional(e.Username),
+				Password: e.Password,
+			},
+		}
+		if e.URL != "" {
+			item.Login.URIs = append(item.Login.URIs, bitwardenURI{URI: e.URL})
+		}
+		doc.Items = append(doc.Items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+func writeOnePassword(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Title", "Website", "Username", "Password", "Notes"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{e.Title, e.URL, e.Username, e.Password, e.Notes}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
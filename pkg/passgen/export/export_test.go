@@ -0,0 +1,123 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+var testEntries = []Entry{
+	{Title: "github.com", Username: "alice", Password: `p<a"s,s&`, URL: "https://github.com", Notes: "work\naccount"},
+	{Title: "router", Password: "secret"},
+}
+
+func TestURL(t *testing.T) {
+	for name, want := range map[string]string{
+		"github.com":             "https://github.com",
+		"http://intranet/login":  "http://intranet/login",
+		"router":                 "",
+		"my bank.example":        "",
+		"login.example.org:8443": "https://login.example.org:8443",
+	} {
+		if got := URL(name); got != want {
+			t.Errorf("URL(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWrite_KeePassXML(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, KeePassXML, testEntries); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc keePassFile
+	if err := xml.Unmarshal([]byte(sb.String()), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, sb.String())
+	}
+	entries := doc.Group.Entries
+	if len(entries) != 2 || doc.Group.Name != "passgen" {
+		t.Fatalf("got %d entries in group %q, want 2 in passgen", len(entries), doc.Group.Name)
+	}
+	got := map[string]keePassValue{}
+	for _, s := range entries[0].Strings {
+		got[s.Key] = s.Value
+	}
+	if got["Password"].Text != testEntries[0].Password || got["Password"].Protect != "True" {
+		t.Errorf("Password = %+v, want %q protected in memory", got["Password"], testEntries[0].Password)
+	}
+	if got["UserName"].Text != "alice" || got["URL"].Text != "https://github.com" || got["Notes"].Text != "work\naccount" {
+		t.Errorf("strings = %+v", got)
+	}
+	if entries[0].UUID != keePassUUID("github.com") || entries[0].UUID == entries[1].UUID {
+		t.Error("entry UUIDs should be stable and distinct")
+	}
+}
+
+func TestWrite_BitwardenJSON(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, BitwardenJSON, testEntries); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Encrypted bool
+		Items     []struct {
+			Type  int
+			Name  string
+			Notes *string
+			Login struct {
+				URIs     []struct{ URI string }
+				Username *string
+				Password string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(sb.String()), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.Encrypted || len(doc.Items) != 2 {
+		t.Fatalf("export = %s", sb.String())
+	}
+	first, second := doc.Items[0], doc.Items[1]
+	if first.Type != 1 || first.Name != "github.com" || first.Login.Password != testEntries[0].Password ||
+		*first.Login.Username != "alice" || first.Login.URIs[0].URI != "https://github.com" || *first.Notes != "work\naccount" {
+		t.Errorf("first item = %+v", first)
+	}
+	if second.Notes != nil || second.Login.Username != nil || len(second.Login.URIs) != 0 {
+		t.Errorf("empty fields of the second item should be null or empty: %+v", second)
+	}
+}
+
+func TestWrite_OnePasswordCSV(t *testing.T) {
+	var sb strings.Builder
+	if err := Write(&sb, OnePasswordCSV, testEntries); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(sb.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Title", "Website", "Username", "Password", "Notes"},
+		{"github.com", "https://github.com", "alice", testEntries[0].Password, "work\naccount"},
+		{"router", "", "", "secret", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := Write(&strings.Builder{}, "lastpass-csv", testEntries); err == nil {
+		t.Error("Write() with an unknown format should fail")
+	}
+}
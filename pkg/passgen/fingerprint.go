@@ -0,0 +1,98 @@
+package passgen
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	fingerprintSize       = 8
+	fingerprintIterations = 20000
+)
+
+type FingerprintStyle string
+
+const (
+	FingerprintWords     FingerprintStyle = "words"
+	FingerprintEmoji     FingerprintStyle = "emoji"
+	FingerprintIdenticon FingerprintStyle = "identicon"
+)
+
+type Fingerprint [fingerprintSize]byte
+
+// NewFingerprint derives a short fingerprint of salt, and of input when it is
+// not empty, so users can recognize a mistyped salt before using a password.
+// The derivation is domain-separated from password generation and uses PBKDF2
+// so that the fingerprint is slow to test guesses against.
+func NewFingerprint(salt, input string) (Fingerprint, error) {
+	domain := "passgen/fingerprint/v1/salt"
+	secret := salt
+	if input != "" {
+		domain = "passgen/fingerprint/v1/salt+input"
+		secret = strconv.Itoa(len(salt)) + ":" + salt + input
+	}
+
+	var f Fingerprint
+	key, err := pbkdf2.Key(sha256.New, secret, []byte(domain), fingerprintIterations, fingerprintSize)
+	if err != nil {
+		return f, fmt.Errorf("fingerprint: %w", err)
+	}
+	copy(f[:], key)
+	return f, nil
+}
+
+func (f Fingerprint) Render(style FingerprintStyle, color bool) (string, error) {
+	switch style {
+	case FingerprintWords:
+		return f.Words(), nil
+	case FingerprintEmoji:
+		return f.Emoji(), nil
+	case FingerprintIdenticon:
+		return f.Identicon(color), nil
+	default:
+		return "", fmt.Errorf("invalid fingerprint style %q", style)
+	}
+}
+
+func (f Fingerprint) Words() string {
+	return fingerprintWords[f[0]] + "-" + fingerprintWords[f[1]] + "-" + fingerprintWords[f[2]]
+}
+
+func (f Fingerprint) Emoji() string {
+	bits := uint32(f[0])<<16 | uint32(f[1])<<8 | uint32(f[2])
+
+	var sb strings.Builder
+	for i := 3; i >= 0; i-- {
+		sb.WriteString(fingerprintEmoji[(bits>>(6*i))&0x3f])
+	}
+	return sb.String()
+}
+
+// Identicon renders a horizontally mirrored 5x5 grid. Each line is
+// terminated by a newline.
+func (f Fingerprint) Identicon(color bool) string {
+	bits := uint16(f[3])<<8 | uint16(f[4])
+
+	var sb strings.Builder
+	for row := 0; row < 5; row++ {
+		if color {
+			fmt.Fprintf(&sb, "\x1b[%dm", 31+int(f[5])%6)
+		}
+		for col := 0; col < 5; col++ {
+			c := min(col, 4-col)
+			if bits&(1<<(row*3+c)) != 0 {
+				sb.WriteString("██")
+			} else {
+				sb.WriteString("  ")
+			}
+		}
+		if color {
+			sb.WriteString("\x1b[0m")
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,43 @@
+package passgen
+
+var fingerprintWords = [256]string{
+	"acid", "acorn", "actor", "adobe", "agent", "album", "alert", "alley",
+	"almond", "amber", "angel", "ankle", "apple", "apron", "arch", "arrow",
+	"atlas", "attic", "audio", "autumn", "axle", "bacon", "badge", "bagel",
+	"bamboo", "banana", "band", "banjo", "barn", "basil", "basin", "beach",
+	"beacon", "bean", "beaver", "bell", "belt", "bench", "bike", "birch",
+	"bison", "blade", "blaze", "board", "boat", "bolt", "bone", "bonus",
+	"boot", "bottle", "bowl", "brain", "brass", "brick", "bridge", "broom",
+	"brush", "bugle", "bulb", "bunny", "butter", "cabin", "cactus", "cake",
+	"camel", "camera", "canal", "canoe", "canyon", "carbon", "carpet", "carrot",
+	"cedar", "cello", "chair", "chalk", "cherry", "chess", "chest", "chili",
+	"circle", "clam", "cliff", "clock", "cloud", "clover", "cobra", "cocoa",
+	"comet", "copper", "coral", "cougar", "cowboy", "crab", "crane", "cream",
+	"crown", "cube", "daisy", "dancer", "desert", "dingo", "donkey", "dragon",
+	"drum", "earth", "easel", "echo", "elbow", "elk", "engine", "falcon",
+	"fern", "ferry", "fig", "finch", "fire", "flag", "flame", "forest",
+	"fossil", "fox", "frog", "galaxy", "garlic", "gecko", "geyser", "ginger",
+	"goat", "gold", "goose", "grape", "gravel", "hammer", "harbor", "harp",
+	"hawk", "hazel", "heron", "hill", "honey", "hornet", "horse", "iris",
+	"island", "ivory", "jacket", "jam", "jelly", "jewel", "jungle", "kayak",
+	"kiwi", "koala", "ladder", "lagoon", "lake", "lava", "lemon", "lens",
+	"lily", "lime", "lizard", "llama", "locket", "lotus", "mango", "maple",
+	"marble", "meadow", "melon", "mint", "mirror", "moose", "moss", "motor",
+	"mule", "nectar", "needle", "nest", "nickel", "nut", "oak", "oasis",
+	"ocean", "onion", "orbit", "orchid", "otter", "owl", "paddle", "palm",
+	"panda", "paper", "parrot", "peanut", "pearl", "pebble", "pepper", "piano",
+	"pillow", "pine", "pirate", "planet", "pony", "poppy", "potato", "prism",
+	"puzzle", "quartz", "quill", "rabbit", "radar", "radish", "reef", "rhino",
+	"ribbon", "river", "robin", "rose", "ruby", "saddle", "salmon", "satin",
+	"scarf", "shark", "shell", "silver", "sled", "snail", "socket", "sofa",
+	"spider", "squid", "star", "stone", "storm", "sugar", "sun", "swan",
+	"table", "tango", "tomato", "torch", "tulip", "tuna", "turtle", "velvet",
+	"violin", "wagon", "walnut", "walrus", "willow", "window", "wolf", "yacht",
+}
+
+var fingerprintEmoji = [64]string{
+	"🐶", "🐱", "🐭", "🐹", "🐰", "🦊", "🐻", "🐼", "🐨", "🐯", "🦁", "🐮", "🐷", "🐸", "🐵", "🐔",
+	"🐧", "🐦", "🐤", "🦆", "🦅", "🦉", "🦇", "🐺", "🐗", "🐴", "🦄", "🐝", "🐛", "🦋", "🐌", "🐞",
+	"🐢", "🐍", "🦎", "🐙", "🦑", "🦀", "🐡", "🐠", "🐟", "🐬", "🐳", "🦈", "🐊", "🐘", "🦒", "🐪",
+	"🍎", "🍊", "🍋", "🍌", "🍉", "🍇", "🍓", "🍒", "🍑", "🍍", "🥝", "🍅", "🥕", "🌽", "🥑", "🍄",
+}
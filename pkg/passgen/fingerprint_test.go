@@ -0,0 +1,109 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func mustFingerprint(t *testing.T, salt, input string) Fingerprint {
+	t.Helper()
+	f, err := NewFingerprint(salt, input)
+	if err != nil {
+		t.Fatalf("NewFingerprint() error = %v", err)
+	}
+	return f
+}
+
+func TestNewFingerprint_Deterministic(t *testing.T) {
+	f1 := mustFingerprint(t, "salt", "")
+	f2 := mustFingerprint(t, "salt", "")
+	if f1 != f2 {
+		t.Error("NewFingerprint() not deterministic")
+	}
+
+	if mustFingerprint(t, "salt", "") == mustFingerprint(t, "sall", "") {
+		t.Error("A typo in the salt should change the fingerprint")
+	}
+	if mustFingerprint(t, "salt", "") == mustFingerprint(t, "salt", "input") {
+		t.Error("Including the input should change the fingerprint")
+	}
+	if mustFingerprint(t, "ab", "c") == mustFingerprint(t, "a", "bc") {
+		t.Error("Salt and input boundaries should be unambiguous")
+	}
+}
+
+func TestFingerprint_Words(t *testing.T) {
+	words := strings.Split(mustFingerprint(t, "salt", "").Words(), "-")
+	if len(words) != 3 {
+		t.Fatalf("Words() = %v, want 3 words", words)
+	}
+	for _, w := range words {
+		if w == "" {
+			t.Error("Words() contains an empty word")
+		}
+	}
+}
+
+func TestFingerprint_Emoji(t *testing.T) {
+	got := mustFingerprint(t, "salt", "").Emoji()
+	if n := utf8.RuneCountInString(got); n != 4 {
+		t.Errorf("Emoji() = %q has %d runes, want 4", got, n)
+	}
+}
+
+func TestFingerprint_Identicon(t *testing.T) {
+	f := mustFingerprint(t, "salt", "")
+
+	plain := f.Identicon(false)
+	lines := strings.Split(strings.TrimSuffix(plain, "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Identicon() has %d lines, want 5", len(lines))
+	}
+	for _, line := range lines {
+		runes := []rune(line)
+		if len(runes) != 10 {
+			t.Fatalf("Identicon() line %q has %d cells, want 10", line, len(runes))
+		}
+		for i := 0; i < 5; i++ {
+			if runes[i] != runes[9-i] {
+				t.Errorf("Identicon() line %q is not mirrored", line)
+			}
+		}
+	}
+
+	if !strings.Contains(f.Identicon(true), "\x1b[") {
+		t.Error("Identicon(true) should contain ANSI color codes")
+	}
+	if strings.Contains(plain, "\x1b[") {
+		t.Error("Identicon(false) should not contain ANSI color codes")
+	}
+}
+
+func TestFingerprint_Render(t *testing.T) {
+	f := mustFingerprint(t, "salt", "")
+	for _, style := range []FingerprintStyle{FingerprintWords, FingerprintEmoji, FingerprintIdenticon} {
+		if _, err := f.Render(style, false); err != nil {
+			t.Errorf("Render(%s) error = %v", style, err)
+		}
+	}
+	if _, err := f.Render("braille", false); err == nil {
+		t.Error("Render() should reject unknown styles")
+	}
+}
+
+func TestFingerprintSymbols_Unique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, w := range fingerprintWords {
+		if seen[w] {
+			t.Errorf("duplicate fingerprint word %q", w)
+		}
+		seen[w] = true
+	}
+	for _, e := range fingerprintEmoji {
+		if seen[e] {
+			t.Errorf("duplicate fingerprint emoji %q", e)
+		}
+		seen[e] = true
+	}
+}
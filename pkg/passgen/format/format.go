@@ -0,0 +1,57 @@
+// Package format renders generated secrets for humans without changing them.
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Group inserts sep between every size characters of s.
+func Group(s string, size int, sep string) string {
+	groups := Split(s, size)
+	return strings.Join(groups, sep)
+}
+
+// Lines renders each group of s on its own line, prefixed with its 1-based index.
+func Lines(s string, size int) string {
+	groups := Split(s, size)
+	width := len(fmt.Sprint(len(groups)))
+
+	var sb strings.Builder
+	for i, g := range groups {
+		fmt.Fprintf(&sb, "%*d  %s\n", width, i+1, g)
+	}
+	return sb.String()
+}
+
+// Split returns s cut into groups of size characters; the last group may be shorter.
+func Split(s string, size int) []string {
+	runes := []rune(s)
+	if size <= 0 || size >= len(runes) {
+		return []string{s}
+	}
+
+	groups := make([]string, 0, (len(runes)+size-1)/size)
+	for i := 0; i < len(runes); i += size {
+		end := min(i+size, len(runes))
+		groups = append(groups, string(runes[i:end]))
+	}
+	return groups
+}
+
+// ContentLength returns how many secret characters fit in total display
+// characters when a separator of sepLen characters follows every size of them.
+func ContentLength(total, size, sepLen int) int {
+	if size <= 0 || total <= size {
+		return total
+	}
+
+	full := (total + sepLen) / (size + sepLen)
+	n := full * size
+
+	leftover := total - (n + (full-1)*sepLen)
+	if leftover > sepLen {
+		n += leftover - sepLen
+	}
+	return n
+}
@@ -0,0 +1,78 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		in   string
+		size int
+		sep  string
+		want string
+	}{
+		{"abcdefghijkl", 4, "-", "abcd-efgh-ijkl"},
+		{"abcdefghij", 4, "-", "abcd-efgh-ij"},
+		{"abcd", 4, "-", "abcd"},
+		{"abc", 4, "-", "abc"},
+		{"abcdef", 2, " ", "ab cd ef"},
+		{"abcdef", 0, "-", "abcdef"},
+		{"", 4, "-", ""},
+		{"äöüß", 2, "-", "äö-üß"},
+	}
+
+	for _, tt := range tests {
+		if got := Group(tt.in, tt.size, tt.sep); got != tt.want {
+			t.Errorf("Group(%q, %d, %q) = %q, want %q", tt.in, tt.size, tt.sep, got, tt.want)
+		}
+	}
+}
+
+func TestGroup_PreservesSecret(t *testing.T) {
+	secret := "x7K#p2Lq9ZmN!vB4"
+	grouped := Group(secret, 3, "-")
+	if strings.ReplaceAll(grouped, "-", "") != secret {
+		t.Errorf("Group() changed the secret: %q", grouped)
+	}
+}
+
+func TestLines(t *testing.T) {
+	got := Lines("abcdefghij", 4)
+	want := "1  abcd\n2  efgh\n3  ij\n"
+	if got != want {
+		t.Errorf("Lines() = %q, want %q", got, want)
+	}
+
+	got = Lines(strings.Repeat("a", 40), 4)
+	if !strings.HasPrefix(got, " 1  aaaa\n") || !strings.HasSuffix(got, "10  aaaa\n") {
+		t.Errorf("Lines() should right-align indexes, got %q", got)
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	tests := []struct {
+		total, size, sepLen int
+		want                int
+	}{
+		{14, 4, 1, 12},
+		{15, 4, 1, 12},
+		{16, 4, 1, 13},
+		{4, 4, 1, 4},
+		{3, 4, 1, 3},
+		{64, 0, 1, 64},
+		{20, 4, 2, 14},
+	}
+
+	for _, tt := range tests {
+		got := ContentLength(tt.total, tt.size, tt.sepLen)
+		if got != tt.want {
+			t.Errorf("ContentLength(%d, %d, %d) = %d, want %d", tt.total, tt.size, tt.sepLen, got, tt.want)
+		}
+
+		display := len(Group(strings.Repeat("a", got), tt.size, strings.Repeat("-", tt.sepLen)))
+		if display > tt.total {
+			t.Errorf("ContentLength(%d, %d, %d) = %d renders %d characters", tt.total, tt.size, tt.sepLen, got, display)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strconv"
 )
 
+const AlgorithmVersion = 1
+
 type Level string
 
 const (
@@ -28,10 +30,13 @@ var (
 )
 
 type Config struct {
-	Input  string
-	Salt   string
-	Length int
-	Level  Level
+	Input    string
+	Salt     string
+	Length   int
+	Level    Level
+	Encoding Encoding `json:",omitempty"`
+	Counter  int      `json:",omitempty"`
+	Version  int      `json:",omitempty"`
 }
 
 func Generate(cfg Config) (string, error) {
@@ -44,8 +49,18 @@ func Generate(cfg Config) (string, error) {
 	if cfg.Length <= 0 || cfg.Length > 4096 {
 		return "", errors.New("length must be positive and not exceed 4096")
 	}
+	if cfg.Counter < 0 {
+		return "", errors.New("counter must not be negative")
+	}
+	if cfg.Version != 0 && cfg.Version != AlgorithmVersion {
+		return "", errors.New("unsupported algorithm version")
+	}
+
+	if cfg.Encoding != EncodingNone {
+		return generateEncoded(cfg)
+	}
 
-	rng := newDetermRNG(cfg.Salt + cfg.Input + string(cfg.Level) + strconv.Itoa(cfg.Length))
+	rng := newDetermRNG(cfg.Salt + cfg.Input + string(cfg.Level) + strconv.Itoa(cfg.Length) + counterSuffix(cfg.Counter))
 
 	var requiredPools [][]rune
 	var allChars []rune
@@ -93,3 +108,12 @@ func Generate(cfg Config) (string, error) {
 
 	return string(passwordRunes), nil
 }
+
+// counterSuffix keeps counters 0 and 1 on the original seed so that existing
+// passwords stay valid as "counter 1".
+func counterSuffix(counter int) string {
+	if counter <= 1 {
+		return ""
+	}
+	return "\x00" + strconv.Itoa(counter)
+}
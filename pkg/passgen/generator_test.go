@@ -483,6 +483,47 @@ func TestCharacterSets(t *testing.T) {
 	}
 }
 
+func TestGenerate_Counter(t *testing.T) {
+	cfg := Config{Input: "site", Salt: "salt", Length: 16, Level: LevelStrong}
+	base, _ := Generate(cfg)
+
+	cfg.Counter = 1
+	first, _ := Generate(cfg)
+	if first != base {
+		t.Error("Counter 1 should match the default password")
+	}
+
+	cfg.Counter = 2
+	second, _ := Generate(cfg)
+	if second == base {
+		t.Error("Counter 2 should produce a different password")
+	}
+
+	cfg.Counter = -1
+	if _, err := Generate(cfg); err == nil {
+		t.Error("Generate() should reject a negative counter")
+	}
+}
+
+func TestGenerate_Version(t *testing.T) {
+	cfg := Config{Input: "site", Salt: "salt", Length: 16, Level: LevelStrong}
+	base, _ := Generate(cfg)
+
+	cfg.Version = AlgorithmVersion
+	current, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if current != base {
+		t.Error("Version 0 and AlgorithmVersion should produce the same password")
+	}
+
+	cfg.Version = AlgorithmVersion + 1
+	if _, err := Generate(cfg); err == nil {
+		t.Error("Generate() should reject an unsupported algorithm version")
+	}
+}
+
 func containsAny(s, chars string) bool {
 	for _, c := range chars {
 		if strings.ContainsRune(s, c) {
@@ -0,0 +1,177 @@
+package qr
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// Version 1-M "HELLO WORLD" in alphanumeric mode, from the standard's
+	// worked example.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionInfo(t *testing.T) {
+	for _, tt := range []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{L, 0, 0b111011111000100},
+		{M, 0, 0b101010000010010},
+		{Q, 0, 0b011010101011111},
+		{H, 0, 0b001011010001001},
+		{M, 5, 0b100000011001110},
+		{H, 7, 0b000100000111011},
+	} {
+		if got := formatInfo(tt.level, tt.mask); got != tt.want {
+			t.Errorf("formatInfo(%s, %d) = %015b, want %015b", tt.level, tt.mask, got, tt.want)
+		}
+	}
+
+	for version, want := range map[int]int{7: 0x07C94, 21: 0x15683, 40: 0x28C69} {
+		if got := versionInfo(version); got != want {
+			t.Errorf("versionInfo(%d) = %#x, want %#x", version, got, want)
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	for version, want := range map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		36: {6, 24, 50, 76, 102, 128, 154},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	} {
+		if got := alignmentPositions(version); !reflect.DeepEqual(got, want) {
+			t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, L, 17}, {1, M, 14}, {1, Q, 11}, {1, H, 7},
+		{10, M, 213}, {15, H, 220},
+		{40, L, 2953}, {40, M, 2331}, {40, Q, 1663}, {40, H, 1273},
+	} {
+		if got := Capacity(tt.version, tt.level); got != tt.want {
+			t.Errorf("Capacity(%d, %s) = %d, want %d", tt.version, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeVersion_Golden(t *testing.T) {
+	// Produced by an independent encoder for the same version, level and mask.
+	golden := `
+111111100100001111111
+100000100110001000001
+101110101100101011101
+101110101111101011101
+101110101000101011101
+100000101001001000001
+111111101010101111111
+000000001110000000000
+101111100011001111100
+100110010111111100101
+110001111010101101110
+110000010101111111100
+110100101000100011000
+000000001100100110111
+111111100001010001010
+100000101110000001111
+101110101101010001010
+101110101111111110100
+101110101000101001000
+100000100101111011100
+111111101010100000010`
+
+	c, err := EncodeVersion([]byte("passgen"), 1, M, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := matrix(c); got != strings.TrimSpace(golden) {
+		t.Errorf("EncodeVersion() =\n%s\nwant\n%s", got, strings.TrimSpace(golden))
+	}
+}
+
+func matrix(c *Code) string {
+	var b strings.Builder
+	for y := range c.Size {
+		if y > 0 {
+			b.WriteByte('\n')
+		}
+		for x := range c.Size {
+			if c.Dark(x, y) {
+				b.WriteByte('1')
+			} else {
+				b.WriteByte('0')
+			}
+		}
+	}
+	return b.String()
+}
+
+func TestEncode(t *testing.T) {
+	c, err := Encode([]byte(strings.Repeat("x", 64)), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != 5 || c.Size != 37 {
+		t.Errorf("64 bytes at M: version %d size %d, want 5 and 37", c.Version, c.Size)
+	}
+
+	c, err = Encode(make([]byte, Capacity(40, H)), H)
+	if err != nil || c.Version != 40 {
+		t.Errorf("Encode() at full capacity = %v, %v", c, err)
+	}
+	if _, err := Encode(make([]byte, Capacity(40, H)+1), H); err == nil {
+		t.Error("Encode() should reject data larger than version 40")
+	}
+
+	// The finder pattern centres are dark and the dark module is set.
+	for _, p := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}, {8, c.Size - 8}} {
+		if !c.Dark(p[0], p[1]) {
+			t.Errorf("module %v should be dark", p)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	c, _ := EncodeVersion([]byte("x"), 1, L, 0)
+	lines := strings.Split(strings.TrimSuffix(c.Text(2), "\n"), "\n")
+	if len(lines) != 13 {
+		t.Fatalf("Text() has %d lines, want 13", len(lines))
+	}
+	for i, line := range lines {
+		if n := len([]rune(line)); n != 25 {
+			t.Errorf("line %d is %d wide, want 25", i, n)
+		}
+	}
+	if lines[0] != strings.Repeat("█", 25) {
+		t.Errorf("the quiet zone should be light: %q", lines[0])
+	}
+}
+
+func TestImage(t *testing.T) {
+	c, _ := EncodeVersion([]byte("x"), 1, L, 0)
+	img := c.Image(3, 4)
+	if side := img.Bounds().Dx(); side != (21+8)*3 {
+		t.Errorf("image width = %d, want %d", side, (21+8)*3)
+	}
+	if img.GrayAt(0, 0).Y != 0xFF || img.GrayAt(4*3, 4*3).Y != 0 {
+		t.Error("quiet zone should be white and the finder corner black")
+	}
+}
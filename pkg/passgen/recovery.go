@@ -0,0 +1,95 @@
+package passgen
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	recoveryCharset  = "23456789abcdefghjkmnpqrstuvwxyz"
+	MaxRecoveryCodes = 100
+)
+
+type RecoveryFormat struct {
+	Groups    int
+	GroupSize int
+	Separator string
+}
+
+var DefaultRecoveryFormat = RecoveryFormat{
+	Groups:    2,
+	GroupSize: 4,
+	Separator: "-",
+}
+
+// GenerateRecoveryCodes returns n distinct codes drawn from an alphabet without
+// look-alike characters. Codes are derived from cfg.Input and cfg.Salt when an
+// input is given, and from crypto/rand otherwise; cfg.Length and cfg.Level are
+// not used.
+func GenerateRecoveryCodes(cfg Config, n int, format RecoveryFormat) ([]string, error) {
+	if n <= 0 || n > MaxRecoveryCodes {
+		return nil, errors.New("recovery code count must be between 1 and 100")
+	}
+	if format.Groups <= 0 || format.GroupSize <= 0 {
+		return nil, errors.New("recovery code groups and group size must be positive")
+	}
+	if format.Groups*format.GroupSize > 64 {
+		return nil, errors.New("recovery code too long (max 64 characters)")
+	}
+	if !recoverySpaceFits(format.Groups*format.GroupSize, n) {
+		return nil, errors.New("recovery code too short for the requested count")
+	}
+	if len(cfg.Input) > 1000 {
+		return nil, errors.New("input too long")
+	}
+
+	var rng intner
+	var crng *cryptoRNG
+	if cfg.Input != "" {
+		rng = newDetermRNG("recovery-codes\x00" + cfg.Salt + "\x00" + cfg.Input)
+	} else {
+		crng = &cryptoRNG{}
+		rng = crng
+	}
+
+	codes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+
+	for len(codes) < n {
+		code := recoveryCode(rng, format)
+		if crng != nil && crng.err != nil {
+			return nil, crng.err
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+func recoveryCode(rng intner, format RecoveryFormat) string {
+	var sb strings.Builder
+	sb.Grow(format.Groups*format.GroupSize + (format.Groups-1)*len(format.Separator))
+
+	for g := 0; g < format.Groups; g++ {
+		if g > 0 {
+			sb.WriteString(format.Separator)
+		}
+		for i := 0; i < format.GroupSize; i++ {
+			sb.WriteByte(recoveryCharset[rng.Intn(len(recoveryCharset))])
+		}
+	}
+
+	return sb.String()
+}
+
+func recoverySpaceFits(codeLen, n int) bool {
+	space := 1
+	for i := 0; i < codeLen && space < n; i++ {
+		space *= len(recoveryCharset)
+	}
+	return space >= n
+}
@@ -0,0 +1,116 @@
+package passgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateRecoveryCodes_Deterministic(t *testing.T) {
+	cfg := Config{Input: "sso-backup", Salt: "salt"}
+
+	codes1, err := GenerateRecoveryCodes(cfg, 10, DefaultRecoveryFormat)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+	codes2, err := GenerateRecoveryCodes(cfg, 10, DefaultRecoveryFormat)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	if strings.Join(codes1, ",") != strings.Join(codes2, ",") {
+		t.Errorf("GenerateRecoveryCodes() not deterministic: %v vs %v", codes1, codes2)
+	}
+
+	cfg.Salt = "other"
+	codes3, _ := GenerateRecoveryCodes(cfg, 10, DefaultRecoveryFormat)
+	if codes1[0] == codes3[0] {
+		t.Error("Different salts should produce different recovery codes")
+	}
+}
+
+func TestGenerateRecoveryCodes_IgnoresLengthAndLevel(t *testing.T) {
+	a, _ := GenerateRecoveryCodes(Config{Input: "in", Salt: "s", Length: 8, Level: LevelLow}, 5, DefaultRecoveryFormat)
+	b, _ := GenerateRecoveryCodes(Config{Input: "in", Salt: "s", Length: 64, Level: LevelStrong}, 5, DefaultRecoveryFormat)
+
+	if strings.Join(a, ",") != strings.Join(b, ",") {
+		t.Errorf("Length and Level should not affect recovery codes: %v vs %v", a, b)
+	}
+}
+
+func TestGenerateRecoveryCodes_Format(t *testing.T) {
+	format := RecoveryFormat{Groups: 3, GroupSize: 5, Separator: " "}
+	codes, err := GenerateRecoveryCodes(Config{}, 20, format)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	if len(codes) != 20 {
+		t.Fatalf("GenerateRecoveryCodes() returned %d codes, want 20", len(codes))
+	}
+
+	for _, code := range codes {
+		groups := strings.Split(code, " ")
+		if len(groups) != 3 {
+			t.Errorf("code %q has %d groups, want 3", code, len(groups))
+		}
+		for _, g := range groups {
+			if len(g) != 5 {
+				t.Errorf("code %q has group %q, want length 5", code, g)
+			}
+			for _, r := range g {
+				if !strings.ContainsRune(recoveryCharset, r) {
+					t.Errorf("code %q contains %c outside recoveryCharset", code, r)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateRecoveryCodes_Unique(t *testing.T) {
+	format := RecoveryFormat{Groups: 1, GroupSize: 2, Separator: "-"}
+	codes, err := GenerateRecoveryCodes(Config{Input: "tiny"}, 100, format)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes() error = %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range codes {
+		if seen[c] {
+			t.Errorf("duplicate recovery code %q", c)
+		}
+		seen[c] = true
+	}
+}
+
+func TestGenerateRecoveryCodes_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		n      int
+		format RecoveryFormat
+	}{
+		{"zero count", Config{}, 0, DefaultRecoveryFormat},
+		{"count too large", Config{}, MaxRecoveryCodes + 1, DefaultRecoveryFormat},
+		{"zero groups", Config{}, 1, RecoveryFormat{GroupSize: 4}},
+		{"zero group size", Config{}, 1, RecoveryFormat{Groups: 2}},
+		{"code too long", Config{}, 1, RecoveryFormat{Groups: 9, GroupSize: 8}},
+		{"space too small", Config{}, 50, RecoveryFormat{Groups: 1, GroupSize: 1}},
+		{"input too long", Config{Input: strings.Repeat("a", 1001)}, 1, DefaultRecoveryFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateRecoveryCodes(tt.cfg, tt.n, tt.format); err == nil {
+				t.Error("GenerateRecoveryCodes() should return error")
+			}
+		})
+	}
+}
+
+func TestRecoveryCharset_Unambiguous(t *testing.T) {
+	for _, r := range "01ilo" {
+		if strings.ContainsRune(recoveryCharset, r) {
+			t.Errorf("recoveryCharset should not contain %c", r)
+		}
+	}
+}
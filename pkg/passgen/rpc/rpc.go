@@ -0,0 +1,385 @@
+// Package rpc serves the generators as line-delimited JSON-RPC 2.0, one
+// request or batch per line, so that editors and launchers can keep a single
+// passgen process running.
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/server"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+// MaxLineBytes limits the size of one request line.
+const MaxLineBytes = 64 << 10
+
+// Error codes. The -32700 and -326xx codes are defined by JSON-RPC 2.0;
+// the others are specific to passgen.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+
+	// CodeInvalidConfig means the generator rejected the parameters.
+	CodeInvalidConfig = -32001
+	// CodeNotFound means a named site does not exist.
+	CodeNotFound = -32002
+	// CodeUnavailable means the salt or the site store could not be read.
+	CodeUnavailable = -32003
+)
+
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// GenerateParams is a passgen.Config, or the name of a stored site whose
+// parameters are used for every field left at its zero value.
+type GenerateParams struct {
+	passgen.Config
+	Site string `json:",omitempty"`
+}
+
+type EntropyResult struct {
+	EntropyBits float64 `json:"entropy_bits"`
+}
+
+type FingerprintParams struct {
+	Salt  string
+	Input string                   `json:",omitempty"`
+	Style passgen.FingerprintStyle `json:",omitempty"`
+}
+
+type FingerprintResult struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+type ListSitesResult struct {
+	Sites []site.Site `json:"sites"`
+}
+
+// Server answers requests. Both hooks are optional.
+type Server struct {
+	// Salt supplies the salt for requests that leave it empty.
+	Salt func() (string, error)
+	// Sites opens the site store for listSites and generate with Site.
+	Sites func() (*site.Store, error)
+}
+
+// Serve reads requests from r until EOF and writes one response line to w
+// for every request that is not a notification.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	br := bufio.NewReaderSize(r, 4096)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	for {
+		line, err := readLine(br)
+		if err == io.EOF {
+			return nil
+		}
+
+		var out any
+		switch {
+		case errors.Is(err, errLineTooLong):
+			out = errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("request larger than %d bytes", MaxLineBytes)})
+		case err != nil:
+			return err
+		default:
+			out = s.handleLine(line)
+		}
+		if out == nil {
+			continue
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+}
+
+var errLineTooLong = errors.New("line too long")
+
+// readLine returns the next non-empty line without its terminator. Lines
+// longer than MaxLineBytes are consumed and reported as errLineTooLong.
+func readLine(br *bufio.Reader) ([]byte, error) {
+	for {
+		var line []byte
+		tooLong := false
+		for {
+			chunk, err := br.ReadSlice('\n')
+			if !tooLong {
+				line = append(line, chunk...)
+				if len(line) > MaxLineBytes+2 {
+					tooLong = true
+					line = nil
+				}
+			}
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err != nil && (err != io.EOF || (len(line) == 0 && !tooLong)) {
+				return nil, err
+			}
+			break
+		}
+		if tooLong {
+			return nil, errLineTooLong
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+// handleLine returns a *Response, a []*Response for a batch, or nil when
+// nothing should be written.
+func (s *Server) handleLine(line []byte) any {
+	if line[0] != '[' {
+		if resp := s.handleMessage(line); resp != nil {
+			return resp
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil {
+		return errorResponse(nil, &Error{Code: CodeParseError, Message: "parse error: " + err.Error()})
+	}
+	if len(batch) == 0 {
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"})
+	}
+	var out []*Response
+	for _, msg := range batch {
+		if resp := s.handleMessage(msg); resp != nil {
+			out = append(out, resp)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func (s *Server) handleMessage(msg []byte) *Response {
+	if !json.Valid(msg) {
+		return errorResponse(nil, &Error{Code: CodeParseError, Message: "parse error: invalid JSON"})
+	}
+
+	var req Request
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "invalid request: " + err.Error()})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: CodeInvalidRequest, Message: `invalid request: jsonrpc must be "2.0" and method is required`})
+	}
+
+	result, rpcErr := s.call(req.Method, req.Params)
+	if req.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr)
+	}
+	return &Response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func errorResponse(id json.RawMessage, err *Error) *Response {
+	return &Response{JSONRPC: "2.0", ID: id, Error: err}
+}
+
+func (s *Server) call(method string, params json.RawMessage) (any, *Error) {
+	switch method {
+	case "generate":
+		var p GenerateParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		return s.generate(p)
+
+	case "random":
+		var p server.RandomRequest
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		resp, err := server.Random(p)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidConfig, Message: err.Error()}
+		}
+		return resp, nil
+
+	case "entropy":
+		var cfg passgen.Config
+		if err := decodeParams(params, &cfg); err != nil {
+			return nil, err
+		}
+		bits, err := passgen.Entropy(cfg)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidConfig, Message: err.Error()}
+		}
+		return EntropyResult{EntropyBits: math.Round(bits*100) / 100}, nil
+
+	case "verify":
+		var p server.VerifyRequest
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := s.fillSalt(&p.Salt); err != nil {
+			return nil, err
+		}
+		resp, err := server.Verify(p)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidConfig, Message: err.Error()}
+		}
+		return resp, nil
+
+	case "listSites":
+		if err := decodeParams(params, &struct{}{}); err != nil {
+			return nil, err
+		}
+		store, err := s.openSites()
+		if err != nil {
+			return nil, err
+		}
+		sites := store.List()
+		if sites == nil {
+			sites = []site.Site{}
+		}
+		return ListSitesResult{Sites: sites}, nil
+
+	case "fingerprint":
+		var p FingerprintParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if err := s.fillSalt(&p.Salt); err != nil {
+			return nil, err
+		}
+		f, err := passgen.NewFingerprint(p.Salt, p.Input)
+		if err != nil {
+			return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		fp, err := f.Render(cmp.Or(p.Style, passgen.FingerprintWords), false)
+		if err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return FingerprintResult{Fingerprint: fp}, nil
+	}
+
+	return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("method not found: %s", method), Data: map[string]string{"method": method}}
+}
+
+func (s *Server) generate(p GenerateParams) (any, *Error) {
+	cfg := p.Config
+	if p.Site != "" {
+		if cfg.Input != "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "invalid params: Site and Input are mutually exclusive"}
+		}
+		store, rpcErr := s.openSites()
+		if rpcErr != nil {
+			return nil, rpcErr
+		}
+		entry, err := store.Get(p.Site)
+		if errors.Is(err, site.ErrNotFound) {
+			return nil, &Error{Code: CodeNotFound, Message: err.Error(), Data: map[string]string{"site": p.Site}}
+		} else if err != nil {
+			return nil, &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		stored := entry.Config(cfg.Salt)
+		if cfg.Length == 0 {
+			cfg.Length = stored.Length
+		}
+		if cfg.Level == "" {
+			cfg.Level = stored.Level
+		}
+		if cfg.Encoding == passgen.EncodingNone {
+			cfg.Encoding = stored.Encoding
+		}
+		if cfg.Counter == 0 {
+			cfg.Counter = stored.Counter
+		}
+		if cfg.Version == 0 {
+			cfg.Version = stored.Version
+		}
+		cfg.Input = stored.Input
+	}
+
+	if err := s.fillSalt(&cfg.Salt); err != nil {
+		return nil, err
+	}
+	resp, err := server.Generate(cfg)
+	if err != nil {
+		return nil, &Error{Code: CodeInvalidConfig, Message: err.Error()}
+	}
+	return resp, nil
+}
+
+func (s *Server) fillSalt(salt *string) *Error {
+	if *salt != "" || s.Salt == nil {
+		return nil
+	}
+	v, err := s.Salt()
+	if err != nil {
+		return &Error{Code: CodeUnavailable, Message: "salt unavailable: " + err.Error()}
+	}
+	*salt = v
+	return nil
+}
+
+func (s *Server) openSites() (*site.Store, *Error) {
+	if s.Sites == nil {
+		return nil, &Error{Code: CodeUnavailable, Message: "no site store is configured"}
+	}
+	store, err := s.Sites()
+	if err != nil {
+		return nil, &Error{Code: CodeUnavailable, Message: "site store unavailable: " + err.Error()}
+	}
+	return store, nil
+}
+
+// decodeParams decodes a by-name params object into v. Missing params are
+// treated as an empty object.
+func decodeParams(params json.RawMessage, v any) *Error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if params[0] != '{' {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: params must be an object"}
+	}
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+	"github.com/zapsaang/pass-gen/pkg/passgen/site"
+)
+
+func serve(t *testing.T, s *Server, input string) []map[string]any {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var got []map[string]any
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			var batch []map[string]any
+			if err := json.Unmarshal([]byte(line), &batch); err != nil {
+				t.Fatalf("invalid batch response %q: %v", line, err)
+			}
+			got = append(got, batch...)
+			continue
+		}
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		got = append(got, resp)
+	}
+	return got
+}
+
+func errorCode(resp map[string]any) int {
+	e, _ := resp["error"].(map[string]any)
+	code, _ := e["code"].(float64)
+	return int(code)
+}
+
+func TestServe_Generate(t *testing.T) {
+	s := &Server{Salt: func() (string, error) { return "default-salt", nil }}
+	explicit, _ := passgen.Generate(passgen.Config{Input: "github.com", Salt: "salt", Length: 20, Level: passgen.LevelStrong})
+	fallback, _ := passgen.Generate(passgen.Config{Input: "github.com", Salt: "default-salt", Length: 20, Level: passgen.LevelStrong})
+
+	got := serve(t, s, `{"jsonrpc": "2.0", "id": 1, "method": "generate", "params": {"Input": "github.com", "Salt": "salt", "Length": 20, "Level": "strong"}}
+{"jsonrpc": "2.0", "id": "two", "method": "generate", "params": {"Input": "github.com", "Length": 20, "Level": "strong"}}
+`)
+	if len(got) != 2 {
+		t.Fatalf("got %d responses, want 2", len(got))
+	}
+	for i, want := range []struct {
+		id       any
+		password string
+	}{{float64(1), explicit}, {"two", fallback}} {
+		result, _ := got[i]["result"].(map[string]any)
+		if got[i]["id"] != want.id || result["password"] != want.password {
+			t.Errorf("response %d = %v, want id %v and password %s", i, got[i], want.id, want.password)
+		}
+		if config := result["config"].(map[string]any); config["Salt"] != "" {
+			t.Errorf("response %d echoes the salt", i)
+		}
+	}
+}
+
+func TestServe_Site(t *testing.T) {
+	store, err := site.Open(filepath.Join(t.TempDir(), "sites.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := site.Site{Name: "github.com", Length: 24, Level: passgen.LevelStrong, Counter: 3, AlgorithmVersion: passgen.AlgorithmVersion}
+	if err := store.Put(entry); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{Sites: func() (*site.Store, error) { return store, nil }}
+	want, _ := passgen.Generate(entry.Config("salt"))
+
+	got := serve(t, s, `{"jsonrpc": "2.0", "id": 1, "method": "generate", "params": {"Site": "github.com", "Salt": "salt"}}
+{"jsonrpc": "2.0", "id": 2, "method": "generate", "params": {"Site": "nope"}}
+{"jsonrpc": "2.0", "id": 3, "method": "generate", "params": {"Site": "github.com", "Input": "x"}}
+{"jsonrpc": "2.0", "id": 4, "method": "listSites"}
+`)
+	if result, _ := got[0]["result"].(map[string]any); result["password"] != want {
+		t.Errorf("generate with Site = %v, want %s", got[0], want)
+	}
+	if errorCode(got[1]) != CodeNotFound {
+		t.Errorf("unknown site = %v, want code %d", got[1], CodeNotFound)
+	}
+	if errorCode(got[2]) != CodeInvalidParams {
+		t.Errorf("Site with Input = %v, want code %d", got[2], CodeInvalidParams)
+	}
+	sites := got[3]["result"].(map[string]any)["sites"].([]any)
+	if len(sites) != 1 || sites[0].(map[string]any)["name"] != "github.com" {
+		t.Errorf("listSites = %v", got[3])
+	}
+}
+
+func TestServe_Methods(t *testing.T) {
+	password, _ := passgen.Generate(passgen.Config{Input: "site", Salt: "salt", Length: 16, Level: passgen.LevelMedium})
+	fp, err := passgen.NewFingerprint("salt", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fingerprint := fp.Words()
+
+	got := serve(t, &Server{}, `{"jsonrpc": "2.0", "id": 1, "method": "random", "params": {"Length": 16, "Encoding": "hex"}}
+{"jsonrpc": "2.0", "id": 2, "method": "entropy", "params": {"Length": 16, "Level": "strong"}}
+{"jsonrpc": "2.0", "id": 3, "method": "verify", "params": {"Input": "site", "Salt": "salt", "Length": 16, "Level": "medium", "Candidate": "`+password+`"}}
+{"jsonrpc": "2.0", "id": 4, "method": "fingerprint", "params": {"Salt": "salt"}}
+`)
+	if len(got) != 4 {
+		t.Fatalf("got %d responses, want 4", len(got))
+	}
+	if s, _ := got[0]["result"].(map[string]any)["password"].(string); len(s) != 32 {
+		t.Errorf("random = %v, want 32 hex characters", got[0])
+	}
+	if bits := got[1]["result"].(map[string]any)["entropy_bits"]; bits != float64(102) {
+		t.Errorf("entropy = %v, want 102", bits)
+	}
+	if got[2]["result"].(map[string]any)["match"] != true {
+		t.Errorf("verify = %v, want a match", got[2])
+	}
+	if got[3]["result"].(map[string]any)["fingerprint"] != fingerprint {
+		t.Errorf("fingerprint = %v, want %s", got[3], fingerprint)
+	}
+}
+
+func TestServe_Errors(t *testing.T) {
+	s := &Server{Salt: func() (string, error) { return "", errors.New("agent is locked") }}
+
+	tests := []struct {
+		name string
+		line string
+		want int
+	}{
+		{"parse error", `{"jsonrpc": "2.0", "id": 1,`, CodeParseError},
+		{"wrong version", `{"jsonrpc": "1.0", "id": 1, "method": "random"}`, CodeInvalidRequest},
+		{"unknown member", `{"jsonrpc": "2.0", "id": 1, "method": "random", "extra": 1}`, CodeInvalidRequest},
+		{"empty batch", `[]`, CodeInvalidRequest},
+		{"unknown method", `{"jsonrpc": "2.0", "id": 1, "method": "nope"}`, CodeMethodNotFound},
+		{"positional params", `{"jsonrpc": "2.0", "id": 1, "method": "random", "params": [16]}`, CodeInvalidParams},
+		{"unknown param", `{"jsonrpc": "2.0", "id": 1, "method": "random", "params": {"Colour": 1}}`, CodeInvalidParams},
+		{"invalid config", `{"jsonrpc": "2.0", "id": 1, "method": "generate", "params": {"Input": "x", "Salt": "s", "Level": "extreme"}}`, CodeInvalidConfig},
+		{"no site store", `{"jsonrpc": "2.0", "id": 1, "method": "listSites"}`, CodeUnavailable},
+		{"salt unavailable", `{"jsonrpc": "2.0", "id": 1, "method": "generate", "params": {"Input": "x", "Length": 8, "Level": "low"}}`, CodeUnavailable},
+		{"too long", strings.Repeat("x", MaxLineBytes+10), CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(t, s, tt.line+"\n")
+			if len(got) != 1 || errorCode(got[0]) != tt.want {
+				t.Errorf("responses = %v, want one error with code %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServe_Notifications(t *testing.T) {
+	got := serve(t, &Server{}, `{"jsonrpc": "2.0", "method": "random"}
+
+[{"jsonrpc": "2.0", "method": "random"}, {"jsonrpc": "2.0", "id": 7, "method": "random", "params": {"Length": 4}}]
+{"jsonrpc": "2.0", "method": "nope"}`)
+	if len(got) != 1 || got[0]["id"] != float64(7) {
+		t.Errorf("responses = %v, want only the response to id 7", got)
+	}
+}
@@ -0,0 +1,232 @@
+// Package server exposes the generators over a small JSON HTTP API meant to
+// be served on a Unix socket or a loopback address. Every request needs a
+// bearer token.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+// MaxRequestBytes limits the size of a request body.
+const MaxRequestBytes = 64 << 10
+
+// GenerateResponse is returned by /v1/generate. Config mirrors the request
+// with the salt removed.
+type GenerateResponse struct {
+	Password    string         `json:"password"`
+	Config      passgen.Config `json:"config"`
+	EntropyBits float64        `json:"entropy_bits"`
+}
+
+type RandomRequest struct {
+	Length   int
+	Encoding passgen.Encoding `json:",omitempty"`
+}
+
+type RandomResponse struct {
+	Password    string        `json:"password"`
+	Config      RandomRequest `json:"config"`
+	EntropyBits float64       `json:"entropy_bits"`
+}
+
+// VerifyRequest is a passgen.Config plus the candidate to check.
+type VerifyRequest struct {
+	passgen.Config
+	Candidate string
+}
+
+type VerifyResponse struct {
+	Match bool `json:"match"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	token []byte
+	mux   *http.ServeMux
+}
+
+// New returns the API handler. Requests must carry "Authorization: Bearer
+// <token>".
+func New(token string) (http.Handler, error) {
+	if len(token) < 16 {
+		return nil, errors.New("server token must be at least 16 characters")
+	}
+
+	h := &handler{token: []byte(token), mux: http.NewServeMux()}
+	h.mux.HandleFunc("/v1/generate", post(h.generate))
+	h.mux.HandleFunc("/v1/random", post(h.random))
+	h.mux.HandleFunc("/v1/verify", post(h.verify))
+	return h, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="passgen"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *handler) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(auth, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), h.token) == 1
+}
+
+func (h *handler) generate(w http.ResponseWriter, r *http.Request) {
+	var cfg passgen.Config
+	if !decode(w, r, &cfg) {
+		return
+	}
+	resp, err := Generate(cfg)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) random(w http.ResponseWriter, r *http.Request) {
+	var req RandomRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	resp, err := Random(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *handler) verify(w http.ResponseWriter, r *http.Request) {
+	var req VerifyRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	resp, err := Verify(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Generate runs the deterministic generator for cfg. It is shared with other
+// front ends that need the same response shape.
+func Generate(cfg passgen.Config) (GenerateResponse, error) {
+	password, err := passgen.Generate(cfg)
+	if err != nil {
+		return GenerateResponse{}, err
+	}
+	bits, _ := passgen.Entropy(cfg)
+	cfg.Salt = ""
+	return GenerateResponse{Password: password, Config: cfg, EntropyBits: roundBits(bits)}, nil
+}
+
+// Random generates a random string, or Length random bytes in Encoding.
+func Random(req RandomRequest) (RandomResponse, error) {
+	if req.Length < 0 {
+		return RandomResponse{}, errors.New("length must not be negative")
+	}
+
+	var s string
+	var err error
+	var bits float64
+	if req.Encoding != passgen.EncodingNone {
+		s, err = passgen.GenerateRandomEncoded(req.Length, req.Encoding)
+		bits = 8 * float64(req.Length)
+	} else {
+		s, err = passgen.GenerateRandomString(req.Length)
+		req.Length = len(s)
+		bits = passgen.RandomStringEntropy(len(s))
+	}
+	if err != nil {
+		return RandomResponse{}, err
+	}
+	return RandomResponse{Password: s, Config: req, EntropyBits: roundBits(bits)}, nil
+}
+
+// Verify reports whether req.Candidate is the password for req.Config.
+func Verify(req VerifyRequest) (VerifyResponse, error) {
+	if req.Candidate == "" {
+		return VerifyResponse{}, errors.New("candidate is required")
+	}
+	matches, err := passgen.VerifySearch(req.Config, req.Candidate, passgen.SearchSpace{})
+	if err != nil {
+		return VerifyResponse{}, err
+	}
+	return VerifyResponse{Match: len(matches) > 0}, nil
+}
+
+func post(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		fn(w, r)
+	}
+}
+
+// decode reads a single JSON object from the request body into v, writing
+// an error response and returning false if it cannot.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mt != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "content type must be application/json")
+		return false
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body larger than %d bytes", MaxRequestBytes))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		writeError(w, http.StatusBadRequest, "invalid JSON: unexpected data after object")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorResponse{Error: msg})
+}
+
+func roundBits(bits float64) float64 {
+	return math.Round(bits*100) / 100
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+const testToken = "pgs_0123456789abcdef"
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	h, err := New(testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, token, contentType, body string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	return resp, out
+}
+
+func TestNew_ShortToken(t *testing.T) {
+	if _, err := New("short"); err == nil {
+		t.Error("New() should reject a short token")
+	}
+}
+
+func TestServer_Generate(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := passgen.Config{Input: "github.com", Salt: "salt", Length: 20, Level: passgen.LevelStrong, Counter: 2}
+	want, _ := passgen.Generate(cfg)
+
+	body, _ := json.Marshal(cfg)
+	resp, out := do(t, srv, http.MethodPost, "/v1/generate", testToken, "application/json", string(body))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body = %v", resp.StatusCode, out)
+	}
+	if out["password"] != want {
+		t.Errorf("password = %v, want %s", out["password"], want)
+	}
+	config := out["config"].(map[string]any)
+	if config["Input"] != "github.com" || config["Salt"] != "" || config["Counter"] != float64(2) {
+		t.Errorf("config = %v, want the request without the salt", config)
+	}
+	if resp.Header.Get("Cache-Control") != "no-store" {
+		t.Error("responses should not be cacheable")
+	}
+}
+
+func TestServer_Random(t *testing.T) {
+	srv := newTestServer(t)
+
+	resp, out := do(t, srv, http.MethodPost, "/v1/random", testToken, "application/json", `{"Length": 16, "Encoding": "hex"}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body = %v", resp.StatusCode, out)
+	}
+	if s, _ := out["password"].(string); len(s) != 32 {
+		t.Errorf("password = %v, want 32 hex characters", out["password"])
+	}
+	if out["entropy_bits"] != float64(128) {
+		t.Errorf("entropy_bits = %v, want 128", out["entropy_bits"])
+	}
+}
+
+func TestServer_Verify(t *testing.T) {
+	srv := newTestServer(t)
+	password, _ := passgen.Generate(passgen.Config{Input: "site", Salt: "salt", Length: 16, Level: passgen.LevelMedium})
+
+	for _, tt := range []struct {
+		candidate string
+		want      bool
+	}{
+		{password, true},
+		{password[:15] + "!", false},
+	} {
+		body := `{"Input": "site", "Salt": "salt", "Level": "medium", "Candidate": "` + tt.candidate + `"}`
+		resp, out := do(t, srv, http.MethodPost, "/v1/verify", testToken, "application/json", body)
+		if resp.StatusCode != http.StatusOK || out["match"] != tt.want {
+			t.Errorf("verify(%q) = %d %v, want match %v", tt.candidate, resp.StatusCode, out, tt.want)
+		}
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := newTestServer(t)
+	valid := `{"Input": "x", "Length": 8, "Level": "low"}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		token       string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"no token", http.MethodPost, "/v1/generate", "", "application/json", valid, http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, "/v1/generate", testToken + "x", "application/json", valid, http.StatusUnauthorized},
+		{"wrong method", http.MethodGet, "/v1/generate", testToken, "", "", http.StatusMethodNotAllowed},
+		{"wrong content type", http.MethodPost, "/v1/generate", testToken, "text/plain", valid, http.StatusUnsupportedMediaType},
+		{"unknown field", http.MethodPost, "/v1/generate", testToken, "application/json", `{"Input": "x", "Colour": 1}`, http.StatusBadRequest},
+		{"trailing data", http.MethodPost, "/v1/generate", testToken, "application/json", valid + valid, http.StatusBadRequest},
+		{"invalid config", http.MethodPost, "/v1/generate", testToken, "application/json", `{"Input": "x", "Length": 8, "Level": "extreme"}`, http.StatusBadRequest},
+		{"too large", http.MethodPost, "/v1/generate", testToken, "application/json", `{"Input": "` + strings.Repeat("a", MaxRequestBytes) + `"}`, http.StatusRequestEntityTooLarge},
+		{"missing candidate", http.MethodPost, "/v1/verify", testToken, "application/json", valid, http.StatusBadRequest},
+		{"not found", http.MethodPost, "/v1/nope", testToken, "application/json", valid, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+// Package site stores the per-site parameters needed to regenerate a
+// deterministic password. It never stores passwords or salts.
+package site
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+const storeVersion = 1
+
+var ErrNotFound = errors.New("site not found")
+
+type Site struct {
+	Name             string           `json:"name"`
+	Username         string           `json:"username,omitempty"`
+	Length           int              `json:"length"`
+	Level            passgen.Level    `json:"level,omitempty"`
+	Encoding         passgen.Encoding `json:"encoding,omitempty"`
+	Counter          int              `json:"counter"`
+	AlgorithmVersion int              `json:"algorithm_version"`
+	Notes            string           `json:"notes,omitempty"`
+	Created          time.Time        `json:"created"`
+	Updated          time.Time        `json:"updated"`
+}
+
+// Config returns the generator configuration for the site. The site name is
+// the input.
+func (s Site) Config(salt string) passgen.Config {
+	return passgen.Config{
+		Input:    s.Name,
+		Salt:     salt,
+		Length:   s.Length,
+		Level:    s.Level,
+		Encoding: s.Encoding,
+		Counter:  s.Counter,
+		Version:  s.AlgorithmVersion,
+	}
+}
+
+// Validate checks that the site name is usable and that its parameters are
+// accepted by the generator.
+func (s Site) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("site name must not be empty")
+	}
+	if strings.ContainsAny(s.Name, "\r\n") {
+		return errors.New("site name must be a single line")
+	}
+	if s.Counter < 1 {
+		return errors.New("counter must be at least 1")
+	}
+	if _, err := passgen.Generate(s.Config("")); err != nil {
+		return err
+	}
+	return nil
+}
+
+type file struct {
+	Version int    `json:"version"`
+	Sites   []Site `json:"sites"`
+}
+
+// Store is a JSON file of sites kept sorted by name.
+type Store struct {
+	path  string
+	sites []Site
+}
+
+// DefaultPath returns $XDG_DATA_HOME/passgen/sites.json, falling back to
+// ~/.local/share/passgen/sites.json.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "passgen", "sites.json")
+}
+
+// Open reads the store at path. A missing file is an empty store that is
+// created on the first Save.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, errors.New("no site store path")
+	}
+	s := &Store{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if f.Version != storeVersion {
+		return nil, fmt.Errorf("%s: unsupported store version %d", path, f.Version)
+	}
+	s.sites = f.Sites
+	s.sort()
+	return s, nil
+}
+
+func (s *Store) Path() string {
+	return s.path
+}
+
+func (s *Store) List() []Site {
+	return append([]Site(nil), s.sites...)
+}
+
+func (s *Store) Get(name string) (Site, error) {
+	if i, ok := s.index(name); ok {
+		return s.sites[i], nil
+	}
+	return Site{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Put adds site or replaces the site with the same name. Created is kept from
+// the existing entry and Updated is set to now.
+func (s *Store) Put(site Site) error {
+	if err := site.Validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	site.Updated = now
+
+	if i, ok := s.index(site.Name); ok {
+		site.Created = s.sites[i].Created
+		s.sites[i] = site
+		return nil
+	}
+
+	if site.Created.IsZero() {
+		site.Created = now
+	}
+	s.sites = append(s.sites, site)
+	s.sort()
+	return nil
+}
+
+func (s *Store) Remove(name string) error {
+	i, ok := s.index(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	s.sites = append(s.sites[:i], s.sites[i+1:]...)
+	return nil
+}
+
+// Save writes the store atomically with mode 0600, creating its directory
+// if needed.
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(file{Version: storeVersion, Sites: s.sites}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".sites-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *Store) index(name string) (int, bool) {
+	i := sort.Search(len(s.sites), func(i int) bool { return s.sites[i].Name >= name })
+	return i, i < len(s.sites) && s.sites[i].Name == name
+}
+
+func (s *Store) sort() {
+	sort.Slice(s.sites, func(i, j int) bool { return s.sites[i].Name < s.sites[j].Name })
+}
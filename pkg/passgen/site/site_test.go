@@ -0,0 +1,164 @@
+package site
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zapsaang/pass-gen/pkg/passgen"
+)
+
+func testSite(name string) Site {
+	return Site{
+		Name:             name,
+		Username:         "alice",
+		Length:           20,
+		Level:            passgen.LevelStrong,
+		Counter:          1,
+		AlgorithmVersion: passgen.AlgorithmVersion,
+	}
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passgen", "sites.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() of a missing store error = %v", err)
+	}
+	for _, name := range []string{"github.com", "example.org", "aws"} {
+		if err := s.Put(testSite(name)); err != nil {
+			t.Fatalf("Put(%q) error = %v", name, err)
+		}
+	}
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("store mode = %o, want 600", perm)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	var names []string
+	for _, site := range s.List() {
+		names = append(names, site.Name)
+	}
+	if got := strings.Join(names, ","); got != "aws,example.org,github.com" {
+		t.Errorf("List() = %s, want sorted names", got)
+	}
+
+	got, err := s.Get("github.com")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Username != "alice" || got.Length != 20 || got.Created.IsZero() || got.Updated.IsZero() {
+		t.Errorf("Get() = %+v", got)
+	}
+}
+
+func TestStore_PutKeepsCreated(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "sites.json"))
+	if err := s.Put(testSite("github.com")); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := s.Get("github.com")
+
+	update := testSite("github.com")
+	update.Counter = 2
+	update.Created = first.Created.AddDate(-1, 0, 0)
+	if err := s.Put(update); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := s.Get("github.com")
+	if got.Counter != 2 || !got.Created.Equal(first.Created) {
+		t.Errorf("Put() update = %+v, want counter 2 and original Created", got)
+	}
+	if len(s.List()) != 1 {
+		t.Errorf("Put() update added a second entry")
+	}
+}
+
+func TestStore_Remove(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "sites.json"))
+	_ = s.Put(testSite("github.com"))
+
+	if err := s.Remove("github.com"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := s.Get("github.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Remove() error = %v, want ErrNotFound", err)
+	}
+	if err := s.Remove("github.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove() of a missing site error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSite_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Site)
+	}{
+		{"empty name", func(s *Site) { s.Name = " " }},
+		{"multi-line name", func(s *Site) { s.Name = "a\nb" }},
+		{"zero counter", func(s *Site) { s.Counter = 0 }},
+		{"bad length", func(s *Site) { s.Length = 0 }},
+		{"bad level", func(s *Site) { s.Level = "extreme" }},
+		{"bad version", func(s *Site) { s.AlgorithmVersion = 99 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := testSite("github.com")
+			tt.modify(&s)
+			if err := s.Validate(); err == nil {
+				t.Error("Validate() should fail")
+			}
+		})
+	}
+}
+
+func TestSite_Config(t *testing.T) {
+	s := testSite("github.com")
+	s.Counter = 3
+
+	want, err := passgen.Generate(passgen.Config{
+		Input: "github.com", Salt: "salt", Length: 20, Level: passgen.LevelStrong, Counter: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := passgen.Generate(s.Config("salt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Error("Site.Config() does not reproduce the equivalent passgen.Config")
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"garbage": "not json",
+		"version": `{"version": 2, "sites": []}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(path); err == nil {
+			t.Errorf("Open(%s) should fail", name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package passgen
+
+import (
+	"errors"
+	"hash/crc32"
+	"strings"
+)
+
+const (
+	DefaultTokenLength  = 30
+	MaxTokenPrefix      = 32
+	tokenChecksumLength = 6
+
+	// base62Alphabet orders the checksum digits as GitHub and the secret
+	// scanners that check its tokens do.
+	base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// GenerateToken returns "<prefix>_<body><checksum>", where body is a random
+// base62 string of the given length and checksum is the CRC32 of
+// "<prefix>_<body>" encoded as six base62 digits (0-9A-Za-z).
+func GenerateToken(prefix string, length int) (string, error) {
+	if err := validateTokenPrefix(prefix); err != nil {
+		return "", err
+	}
+	if length <= 0 {
+		length = DefaultTokenLength
+	}
+
+	body, err := GenerateRandomString(length)
+	if err != nil {
+		return "", err
+	}
+
+	payload := prefix + "_" + body
+	return payload + tokenChecksum(payload), nil
+}
+
+func VerifyToken(token string) error {
+	sep := strings.LastIndexByte(token, '_')
+	if sep <= 0 {
+		return errors.New("malformed token: missing prefix")
+	}
+
+	prefix, rest := token[:sep], token[sep+1:]
+	if err := validateTokenPrefix(prefix); err != nil {
+		return err
+	}
+	if len(rest) <= tokenChecksumLength {
+		return errors.New("malformed token: body too short")
+	}
+	for i := 0; i < len(rest); i++ {
+		if strings.IndexByte(randomCharset, rest[i]) < 0 {
+			return errors.New("malformed token: body is not base62")
+		}
+	}
+
+	split := len(token) - tokenChecksumLength
+	if tokenChecksum(token[:split]) != token[split:] {
+		return errors.New("token checksum mismatch")
+	}
+
+	return nil
+}
+
+func validateTokenPrefix(prefix string) error {
+	if prefix == "" {
+		return errors.New("token prefix is required")
+	}
+	if len(prefix) > MaxTokenPrefix {
+		return errors.New("token prefix too long (max 32)")
+	}
+	if prefix[0] == '_' || prefix[len(prefix)-1] == '_' {
+		return errors.New("token prefix must not start or end with '_'")
+	}
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if c != '_' && strings.IndexByte(randomCharset, c) < 0 {
+			return errors.New("token prefix may only contain letters, digits and '_'")
+		}
+	}
+	return nil
+}
+
+func tokenChecksum(payload string) string {
+	sum := crc32.ChecksumIEEE([]byte(payload))
+
+	out := make([]byte, tokenChecksumLength)
+	for i := tokenChecksumLength - 1; i >= 0; i-- {
+		out[i] = base62Alphabet[sum%62]
+		sum /= 62
+	}
+	return string(out)
+}
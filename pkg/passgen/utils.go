@@ -32,4 +32,25 @@ func GenerateRandomString(length int) (string, error) {
 	}
 
 	return string(b), nil
-}
\ No newline at end of file
+}
+
+type intner interface {
+	Intn(max int) int
+}
+
+type cryptoRNG struct {
+	err error
+}
+
+func (r *cryptoRNG) Intn(max int) int {
+	if max <= 0 || r.err != nil {
+		return 0
+	}
+
+	num, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		r.err = err
+		return 0
+	}
+	return int(num.Int64())
+}
@@ -0,0 +1,140 @@
+package passgen
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+type UUID [16]byte
+
+var (
+	NamespaceDNS  = MustParseUUID("6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	NamespaceURL  = MustParseUUID("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+	NamespaceOID  = MustParseUUID("6ba7b812-9dad-11d1-80b4-00c04fd430c8")
+	NamespaceX500 = MustParseUUID("6ba7b814-9dad-11d1-80b4-00c04fd430c8")
+)
+
+// GenerateUUID returns an RFC 9562 UUID of the given version. Versions 4 and 7
+// are random and ignore cfg. Version 8 is derived from cfg.Salt and cfg.Input,
+// and version 5 hashes cfg.Input under a namespace derived from cfg.Salt, so
+// both are reproducible.
+func GenerateUUID(cfg Config, version int) (UUID, error) {
+	switch version {
+	case 4:
+		return NewUUIDv4()
+	case 7:
+		return NewUUIDv7(time.Now())
+	case 5, 8:
+		if cfg.Input == "" {
+			return UUID{}, errors.New("input is required")
+		}
+		if len(cfg.Input) > 1000 {
+			return UUID{}, errors.New("input too long")
+		}
+		if version == 8 {
+			return saltedUUID("uuid-v8\x00" + cfg.Salt + "\x00" + cfg.Input), nil
+		}
+		return NewUUIDv5(SaltNamespace(cfg.Salt), cfg.Input), nil
+	default:
+		return UUID{}, errors.New("unsupported UUID version (use 4, 5, 7 or 8)")
+	}
+}
+
+func NewUUIDv4() (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		return UUID{}, err
+	}
+	u.setVersion(4)
+	return u, nil
+}
+
+func NewUUIDv7(t time.Time) (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[6:]); err != nil {
+		return UUID{}, err
+	}
+
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(t.UnixMilli()))
+	copy(u[:6], ts[2:])
+
+	u.setVersion(7)
+	return u, nil
+}
+
+func NewUUIDv5(namespace UUID, name string) UUID {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte(name))
+
+	var u UUID
+	copy(u[:], h.Sum(nil))
+	u.setVersion(5)
+	return u
+}
+
+// SaltNamespace returns the version 8 UUID used as the version 5 namespace
+// for a given salt.
+func SaltNamespace(salt string) UUID {
+	return saltedUUID("uuid-namespace\x00" + salt)
+}
+
+func saltedUUID(seed string) UUID {
+	rng := newDetermRNG(seed)
+
+	var u UUID
+	for i := range u {
+		u[i] = rng.nextByte()
+	}
+	u.setVersion(8)
+	return u
+}
+
+func (u *UUID) setVersion(v byte) {
+	u[6] = u[6]&0x0f | v<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.New("invalid UUID format")
+	}
+
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return UUID{}, errors.New("invalid UUID format")
+	}
+	return u, nil
+}
+
+func MustParseUUID(s string) UUID {
+	u, err := ParseUUID(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,107 @@
+package passgen
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewUUIDv5_RFCVector(t *testing.T) {
+	// RFC 9562 Appendix A.4
+	got := NewUUIDv5(NamespaceDNS, "www.example.com").String()
+	want := "2ed6657d-e927-568b-95e1-2665a8aea6a2"
+	if got != want {
+		t.Errorf("NewUUIDv5() = %s, want %s", got, want)
+	}
+}
+
+func TestNewUUIDv4(t *testing.T) {
+	u1, err := NewUUIDv4()
+	if err != nil {
+		t.Fatalf("NewUUIDv4() error = %v", err)
+	}
+	u2, _ := NewUUIDv4()
+
+	if u1 == u2 {
+		t.Error("NewUUIDv4() produced duplicate UUIDs")
+	}
+	if u1.Version() != 4 {
+		t.Errorf("Version() = %d, want 4", u1.Version())
+	}
+	if u1[8]&0xc0 != 0x80 {
+		t.Errorf("variant bits = %02x, want 10xxxxxx", u1[8])
+	}
+}
+
+func TestNewUUIDv7_Timestamp(t *testing.T) {
+	ts := time.UnixMilli(0x017F22E279B0)
+	u, err := NewUUIDv7(ts)
+	if err != nil {
+		t.Fatalf("NewUUIDv7() error = %v", err)
+	}
+
+	if got := u.String()[:13]; got != "017f22e2-79b0" {
+		t.Errorf("timestamp prefix = %s, want 017f22e2-79b0", got)
+	}
+	if u.Version() != 7 {
+		t.Errorf("Version() = %d, want 7", u.Version())
+	}
+
+	later, _ := NewUUIDv7(ts.Add(time.Millisecond))
+	if later.String() <= u.String() {
+		t.Error("NewUUIDv7() should sort by time")
+	}
+}
+
+func TestGenerateUUID_Deterministic(t *testing.T) {
+	cfg := Config{Input: "tenant-42", Salt: "salt"}
+
+	for _, v := range []int{5, 8} {
+		u1, err := GenerateUUID(cfg, v)
+		if err != nil {
+			t.Fatalf("GenerateUUID(v%d) error = %v", v, err)
+		}
+		u2, _ := GenerateUUID(cfg, v)
+		if u1 != u2 {
+			t.Errorf("GenerateUUID(v%d) not deterministic: %s vs %s", v, u1, u2)
+		}
+		if u1.Version() != v {
+			t.Errorf("GenerateUUID(v%d).Version() = %d", v, u1.Version())
+		}
+
+		other, _ := GenerateUUID(Config{Input: "tenant-42", Salt: "other"}, v)
+		if u1 == other {
+			t.Errorf("GenerateUUID(v%d) should depend on salt", v)
+		}
+	}
+
+	v5, _ := GenerateUUID(cfg, 5)
+	if v5 != NewUUIDv5(SaltNamespace("salt"), "tenant-42") {
+		t.Error("GenerateUUID(v5) should hash input under SaltNamespace(salt)")
+	}
+}
+
+func TestGenerateUUID_Invalid(t *testing.T) {
+	if _, err := GenerateUUID(Config{}, 5); err == nil {
+		t.Error("GenerateUUID(v5) should require input")
+	}
+	if _, err := GenerateUUID(Config{Input: "x"}, 3); err == nil {
+		t.Error("GenerateUUID(v3) should be unsupported")
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	s := "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+	u, err := ParseUUID(s)
+	if err != nil {
+		t.Fatalf("ParseUUID() error = %v", err)
+	}
+	if u.String() != s {
+		t.Errorf("round trip = %s, want %s", u, s)
+	}
+
+	for _, bad := range []string{"", "6ba7b810-9dad-11d1-80b4-00c04fd430c", "6ba7b810x9dad-11d1-80b4-00c04fd430c8", "zba7b810-9dad-11d1-80b4-00c04fd430c8"} {
+		if _, err := ParseUUID(bad); err == nil {
+			t.Errorf("ParseUUID(%q) should return error", bad)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package vault keeps salts and master inputs in a passphrase-protected file.
+// The key is derived with PBKDF2-SHA256 and the contents are sealed with
+// AES-256-GCM; the header is authenticated as additional data.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FormatVersion is the version written to the file header.
+const FormatVersion = 1
+
+// DefaultIterations is the PBKDF2-SHA256 work factor for new vaults.
+const DefaultIterations = 600000
+
+const (
+	magic           = "PGVAULT\x00"
+	kdfPBKDF2SHA256 = 1
+
+	saltSize   = 16
+	nonceSize  = 12
+	keySize    = 32
+	headerSize = len(magic) + 1 + 1 + 4 + saltSize + nonceSize
+
+	maxIterations = 100_000_000
+)
+
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted vault")
+	ErrNotFound        = errors.New("vault entry not found")
+	ErrNotVault        = errors.New("not a passgen vault")
+)
+
+type Kind string
+
+const (
+	KindSalt  Kind = "salt"
+	KindInput Kind = "input"
+)
+
+type Entry struct {
+	Kind    Kind      `json:"kind"`
+	Value   string    `json:"value"`
+	Created time.Time `json:"created"`
+}
+
+type payload struct {
+	Entries map[string]Entry `json:"entries"`
+}
+
+// Vault is the decrypted contents of a vault file together with the
+// passphrase used to seal it again.
+type Vault struct {
+	entries    map[string]Entry
+	passphrase string
+	iterations int
+}
+
+// DefaultPath returns $XDG_DATA_HOME/passgen/vault, falling back to
+// ~/.local/share/passgen/vault.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, "passgen", "vault")
+}
+
+// New returns an empty vault sealed with passphrase.
+func New(passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	return &Vault{entries: map[string]Entry{}, passphrase: passphrase, iterations: DefaultIterations}, nil
+}
+
+// Open reads and decrypts the vault at path.
+func Open(path, passphrase string) (*Vault, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := Decrypt(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return v, nil
+}
+
+// Decrypt opens a sealed vault.
+func Decrypt(data []byte, passphrase string) (*Vault, error) {
+	if len(data) < headerSize || string(data[:len(magic)]) != magic {
+		return nil, ErrNotVault
+	}
+
+	h := data[len(magic):headerSize]
+	if version := h[0]; version != FormatVersion {
+		return nil, fmt.Errorf("unsupported vault version %d", version)
+	}
+	if kdf := h[1]; kdf != kdfPBKDF2SHA256 {
+		return nil, fmt.Errorf("unsupported key derivation %d", kdf)
+	}
+	iterations := int(binary.BigEndian.Uint32(h[2:6]))
+	if iterations < 1 || iterations > maxIterations {
+		return nil, fmt.Errorf("invalid iteration count %d", iterations)
+	}
+	salt := h[6 : 6+saltSize]
+	nonce := h[6+saltSize:]
+
+	aead, err := newAEAD(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, nonce, data[headerSize:], data[:headerSize])
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	var p payload
+	if err := json.Unmarshal(plain, &p); err != nil {
+		return nil, fmt.Errorf("decoding vault: %v", err)
+	}
+	if p.Entries == nil {
+		p.Entries = map[string]Entry{}
+	}
+	return &Vault{entries: p.Entries, passphrase: passphrase, iterations: iterations}, nil
+}
+
+// Encrypt seals the vault with a fresh salt and nonce.
+func (v *Vault) Encrypt() ([]byte, error) {
+	plain, err := json.Marshal(payload{Entries: v.entries})
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	h := header[len(magic):]
+	h[0] = FormatVersion
+	h[1] = kdfPBKDF2SHA256
+	binary.BigEndian.PutUint32(h[2:6], uint32(v.iterations))
+	salt := h[6 : 6+saltSize]
+	nonce := h[6+saltSize:]
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(v.passphrase, salt, v.iterations)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(header, nonce, plain, header), nil
+}
+
+// Save encrypts the vault and replaces the file at path atomically, so an
+// interrupted write or passphrase change leaves either the old or the new
+// vault on disk.
+func (v *Vault) Save(path string) error {
+	data, err := v.Encrypt()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".vault-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// SetPassphrase changes the passphrase used by the next Save. Every entry is
+// re-encrypted under a key derived from a new salt.
+func (v *Vault) SetPassphrase(passphrase string) error {
+	if passphrase == "" {
+		return errors.New("passphrase must not be empty")
+	}
+	v.passphrase = passphrase
+	v.iterations = max(v.iterations, DefaultIterations)
+	return nil
+}
+
+func (v *Vault) Get(name string) (Entry, error) {
+	e, ok := v.entries[name]
+	if !ok {
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return e, nil
+}
+
+// Put stores an entry, replacing any entry with the same name.
+func (v *Vault) Put(name string, e Entry) error {
+	if name == "" {
+		return errors.New("entry name must not be empty")
+	}
+	if e.Kind != KindSalt && e.Kind != KindInput {
+		return fmt.Errorf("invalid entry kind %q (use salt or input)", e.Kind)
+	}
+	if e.Value == "" {
+		return errors.New("entry value must not be empty")
+	}
+	if e.Created.IsZero() {
+		e.Created = time.Now().UTC().Truncate(time.Second)
+	}
+	v.entries[name] = e
+	return nil
+}
+
+func (v *Vault) Remove(name string) error {
+	if _, ok := v.entries[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	delete(v.entries, name)
+	return nil
+}
+
+// Names returns the entry names in sorted order.
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.entries))
+	for name := range v.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
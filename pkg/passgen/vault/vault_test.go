@@ -0,0 +1,171 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestVault uses a low work factor so the tests stay fast.
+func newTestVault(t *testing.T, passphrase string) *Vault {
+	t.Helper()
+	v, err := New(passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.iterations = 1000
+	return v
+}
+
+func TestVault_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passgen", "vault")
+
+	v := newTestVault(t, "correct horse")
+	if err := v.Put("team", Entry{Kind: KindSalt, Value: "team-salt"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Put("master", Entry{Kind: KindInput, Value: "master-input"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("vault mode = %o, want 600", perm)
+	}
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("team-salt")) {
+		t.Error("vault file contains a plaintext value")
+	}
+
+	opened, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if got := opened.Names(); !reflect.DeepEqual(got, []string{"master", "team"}) {
+		t.Errorf("Names() = %v", got)
+	}
+	e, err := opened.Get("team")
+	if err != nil || e.Kind != KindSalt || e.Value != "team-salt" || e.Created.IsZero() {
+		t.Errorf("Get(team) = %+v, %v", e, err)
+	}
+	if opened.iterations != 1000 {
+		t.Errorf("iterations = %d, want the value from the header", opened.iterations)
+	}
+}
+
+func TestVault_WrongPassphrase(t *testing.T) {
+	v := newTestVault(t, "right")
+	_ = v.Put("team", Entry{Kind: KindSalt, Value: "x"})
+	data, err := v.Encrypt()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Decrypt(data, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Decrypt() with wrong passphrase error = %v", err)
+	}
+}
+
+func TestVault_Tamper(t *testing.T) {
+	v := newTestVault(t, "pass")
+	_ = v.Put("team", Entry{Kind: KindSalt, Value: "x"})
+	data, _ := v.Encrypt()
+
+	// Flip one bit in the iteration count (header) and one in the ciphertext.
+	for _, i := range []int{len(magic) + 5, len(data) - 1} {
+		tampered := append([]byte(nil), data...)
+		tampered[i] ^= 1
+		if _, err := Decrypt(tampered, "pass"); !errors.Is(err, ErrWrongPassphrase) {
+			t.Errorf("Decrypt() with byte %d flipped error = %v", i, err)
+		}
+	}
+}
+
+func TestDecrypt_Header(t *testing.T) {
+	v := newTestVault(t, "pass")
+	data, _ := v.Encrypt()
+
+	if _, err := Decrypt([]byte("hello"), "pass"); !errors.Is(err, ErrNotVault) {
+		t.Errorf("Decrypt() of garbage error = %v", err)
+	}
+
+	future := append([]byte(nil), data...)
+	future[len(magic)] = FormatVersion + 1
+	if _, err := Decrypt(future, "pass"); err == nil || errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Decrypt() of a newer version error = %v, want version error", err)
+	}
+}
+
+func TestVault_SetPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault")
+
+	v := newTestVault(t, "old")
+	_ = v.Put("team", Entry{Kind: KindSalt, Value: "team-salt"})
+	if err := v.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.SetPassphrase(""); err == nil {
+		t.Error("SetPassphrase(\"\") should fail")
+	}
+	if err := v.SetPassphrase("new"); err != nil {
+		t.Fatal(err)
+	}
+	v.iterations = 1000
+	if err := v.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Open(path, "old"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Open() with the old passphrase error = %v", err)
+	}
+	rotated, err := Open(path, "new")
+	if err != nil {
+		t.Fatalf("Open() with the new passphrase error = %v", err)
+	}
+	if e, _ := rotated.Get("team"); e.Value != "team-salt" {
+		t.Errorf("entry after rotation = %+v", e)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Save() left temporary files: %v", entries)
+	}
+}
+
+func TestVault_PutRemove(t *testing.T) {
+	v := newTestVault(t, "pass")
+
+	for _, tt := range []struct {
+		name  string
+		entry Entry
+	}{
+		{"", Entry{Kind: KindSalt, Value: "x"}},
+		{"a", Entry{Kind: "key", Value: "x"}},
+		{"a", Entry{Kind: KindSalt}},
+	} {
+		if err := v.Put(tt.name, tt.entry); err == nil {
+			t.Errorf("Put(%q, %+v) should fail", tt.name, tt.entry)
+		}
+	}
+
+	_ = v.Put("a", Entry{Kind: KindSalt, Value: "x"})
+	if err := v.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Get("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Remove() error = %v", err)
+	}
+	if err := v.Remove("a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Remove() of a missing entry error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package passgen
+
+import (
+	"crypto/subtle"
+	"errors"
+	"unicode/utf8"
+)
+
+const MaxSearchCounter = 1000
+
+type SearchSpace struct {
+	Levels   []Level
+	Counters []int
+}
+
+// Verify reports whether candidate is the password generated by cfg. The
+// comparison runs in constant time for candidates of the expected length.
+func Verify(cfg Config, candidate string) bool {
+	password, err := Generate(cfg)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(candidate)) == 1
+}
+
+// VerifySearch tries every combination of levels and counters in space on top
+// of cfg and returns the configurations that reproduce candidate. Empty lists
+// fall back to cfg.Level and cfg.Counter. Levels are not searched when
+// cfg.Encoding is set, as they do not change encoded passwords. A zero
+// cfg.Length is taken from the candidate for unencoded passwords.
+func VerifySearch(cfg Config, candidate string, space SearchSpace) ([]Config, error) {
+	levels := space.Levels
+	if len(levels) == 0 || cfg.Encoding != EncodingNone {
+		levels = []Level{cfg.Level}
+	}
+	counters := space.Counters
+	if len(counters) == 0 {
+		counters = []int{cfg.Counter}
+	}
+	if len(levels)*len(counters) > 3*MaxSearchCounter {
+		return nil, errors.New("search space too large")
+	}
+
+	if cfg.Length == 0 && cfg.Encoding == EncodingNone {
+		cfg.Length = utf8.RuneCountInString(candidate)
+	}
+
+	var matches []Config
+	for _, level := range levels {
+		for _, counter := range counters {
+			try := cfg
+			try.Level = level
+			try.Counter = counter
+
+			password, err := Generate(try)
+			if err != nil {
+				return nil, err
+			}
+			if subtle.ConstantTimeCompare([]byte(password), []byte(candidate)) == 1 {
+				matches = append(matches, try)
+			}
+		}
+	}
+
+	return matches, nil
+}
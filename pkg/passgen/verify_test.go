@@ -0,0 +1,94 @@
+package passgen
+
+import (
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	cfg := Config{Input: "site", Salt: "salt", Length: 16, Level: LevelMedium}
+	password, err := Generate(cfg)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	if !Verify(cfg, password) {
+		t.Error("Verify() should accept the generated password")
+	}
+	if Verify(cfg, password[:15]) {
+		t.Error("Verify() should reject a truncated password")
+	}
+	if Verify(cfg, password+"x") {
+		t.Error("Verify() should reject a longer password")
+	}
+
+	other := cfg
+	other.Salt = "other"
+	if Verify(other, password) {
+		t.Error("Verify() should reject the password under a different salt")
+	}
+
+	if Verify(Config{Length: 16, Level: LevelMedium}, password) {
+		t.Error("Verify() should return false for an invalid config")
+	}
+}
+
+func TestVerifySearch(t *testing.T) {
+	target := Config{Input: "site", Salt: "salt", Length: 20, Level: LevelStrong, Counter: 3}
+	password, err := Generate(target)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	base := Config{Input: "site", Salt: "salt", Level: LevelMedium}
+	space := SearchSpace{
+		Levels:   []Level{LevelLow, LevelMedium, LevelStrong},
+		Counters: []int{1, 2, 3, 4},
+	}
+
+	matches, err := VerifySearch(base, password, space)
+	if err != nil {
+		t.Fatalf("VerifySearch() error = %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("VerifySearch() found %d matches, want 1", len(matches))
+	}
+	if matches[0].Level != LevelStrong || matches[0].Counter != 3 || matches[0].Length != 20 {
+		t.Errorf("VerifySearch() match = %+v, want level strong counter 3 length 20", matches[0])
+	}
+
+	matches, err = VerifySearch(base, password, SearchSpace{})
+	if err != nil {
+		t.Fatalf("VerifySearch() error = %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("VerifySearch() without search space found %d matches, want 0", len(matches))
+	}
+}
+
+func TestVerifySearch_Encoded(t *testing.T) {
+	target := Config{Input: "site", Salt: "salt", Length: 16, Encoding: EncodingHex, Counter: 2}
+	password, err := Generate(target)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	space := SearchSpace{Levels: []Level{LevelLow, LevelMedium, LevelStrong}, Counters: []int{1, 2, 3}}
+	matches, err := VerifySearch(Config{Input: "site", Salt: "salt", Length: 16, Encoding: EncodingHex}, password, space)
+	if err != nil {
+		t.Fatalf("VerifySearch() error = %v", err)
+	}
+	if len(matches) != 1 || matches[0].Counter != 2 {
+		t.Errorf("VerifySearch() = %+v, want one match with counter 2", matches)
+	}
+}
+
+func TestVerifySearch_Invalid(t *testing.T) {
+	if _, err := VerifySearch(Config{Level: LevelLow}, "abc", SearchSpace{}); err == nil {
+		t.Error("VerifySearch() should return error for missing input")
+	}
+
+	counters := make([]int, 3*MaxSearchCounter+1)
+	if _, err := VerifySearch(Config{Input: "x", Level: LevelLow}, "abc", SearchSpace{Counters: counters}); err == nil {
+		t.Error("VerifySearch() should reject an oversized search space")
+	}
+}